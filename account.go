@@ -1,14 +1,215 @@
 package goacmedns
 
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// redactedPassword replaces the password of the accounts formatted with [Account.String].
+const redactedPassword = "REDACTED"
+
+// challengePrefix is the label prepended to a domain to build the name of its ACME DNS-01 challenge record.
+const challengePrefix = "_acme-challenge."
+
 // Account is a struct that holds the registration response from an ACME-DNS server.
 // It represents an API username/key that can be used to update TXT records for the account's subdomain.
 type Account struct {
-	FullDomain string `json:"fulldomain"`
-	SubDomain  string `json:"subdomain"`
-	Username   string `json:"username"`
-	Password   string `json:"password"`
+	FullDomain string `json:"fulldomain" yaml:"fulldomain"`
+	SubDomain  string `json:"subdomain"  yaml:"subdomain"`
+	Username   string `json:"username"   yaml:"username"`
+	Password   string `json:"password"   yaml:"password"`
 
 	// ServerURL contains the URL of the acme-dns server the account was registered with.
 	// (Maybe empty for account instances registered before this field was added).
-	ServerURL string `json:"server_url"`
+	ServerURL string `json:"server_url" yaml:"server_url"`
+
+	// AllowFrom contains the CIDR networks the account is allowed to be used from, as accepted by the server.
+	// (Empty for unrestricted accounts, and for account instances registered before this field was added).
+	AllowFrom []string `json:"allowfrom,omitempty" yaml:"allowfrom,omitempty"`
+
+	// CreatedAt is the time the account was registered at, stamped by [Client.RegisterAccount].
+	// (Zero for account instances registered before this field was added).
+	CreatedAt time.Time `json:"created_at,omitzero" yaml:"created_at,omitempty"`
+
+	// LastUsedAt is the last time the TXT record of the account was updated, when recorded by the storage layer.
+	// (Zero if the account was never used since this field was added).
+	LastUsedAt time.Time `json:"last_used_at,omitzero" yaml:"last_used_at,omitempty"`
+}
+
+// NewAccount returns an [Account] with the given fields, e.g. to import an account registered by another system.
+// Unlike an [Account] literal, the fields are checked: the account must pass [ValidateRegisteredAccount],
+// and the server URL must be an absolute URL. The returned error wraps [ErrInvalidAccount].
+func NewAccount(username, password, subdomain, fullDomain, serverURL string) (Account, error) {
+	acct := Account{
+		FullDomain: fullDomain,
+		SubDomain:  subdomain,
+		Username:   username,
+		Password:   password,
+		ServerURL:  serverURL,
+	}
+
+	err := ValidateRegisteredAccount(acct)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if serverURL == "" {
+		return Account{}, fmt.Errorf("%w: missing server URL", ErrInvalidAccount)
+	}
+
+	_, err = parseBaseURL(serverURL)
+	if err != nil {
+		return Account{}, fmt.Errorf("%w: invalid server URL: %w", ErrInvalidAccount, err)
+	}
+
+	return acct, nil
+}
+
+// Validate checks that the fields required to update the TXT record of the account are not empty,
+// and that the ServerURL, when present, is a valid URL.
+// The returned error wraps [ErrInvalidAccount].
+func (a Account) Validate() error {
+	required := []struct {
+		name  string
+		value string
+	}{
+		{name: "username", value: a.Username},
+		{name: "password", value: a.Password},
+		{name: "subdomain", value: a.SubDomain},
+	}
+
+	for _, field := range required {
+		if field.value == "" {
+			return fmt.Errorf("%w: missing %s", ErrInvalidAccount, field.name)
+		}
+	}
+
+	if a.ServerURL != "" {
+		_, err := url.Parse(a.ServerURL)
+		if err != nil {
+			return fmt.Errorf("%w: invalid server URL: %w", ErrInvalidAccount, err)
+		}
+	}
+
+	return nil
+}
+
+// CNAMERecord returns the name and the target of the CNAME record that delegates
+// the ACME DNS-01 challenge of the given domain to the account's FullDomain.
+// The challenge of a wildcard domain (e.g. `*.example.com`) is the challenge of its base domain.
+// The target is fully-qualified (it ends with a trailing dot).
+func (a Account) CNAMERecord(domain string) (name, target string) {
+	domain = strings.TrimPrefix(strings.TrimSuffix(domain, "."), "*.")
+
+	name = challengePrefix + domain
+	target = strings.TrimSuffix(a.FullDomain, ".") + "."
+
+	return name, target
+}
+
+// Instructions describes the DNS record to provision to complete the setup of an account for a domain,
+// e.g. to render the setup steps in a user interface (see [Account.SetupInstructions]).
+type Instructions struct {
+	// Domain is the domain the account is set up for, as given to [Account.SetupInstructions].
+	Domain string `json:"domain"`
+	// Name is the name of the record (e.g. `_acme-challenge.example.com`).
+	Name string `json:"name"`
+	// Type is the type of the record (always `CNAME`).
+	Type string `json:"type"`
+	// Target is the fully-qualified target of the record (the account's FullDomain with a trailing dot).
+	Target string `json:"target"`
+	// TTL is the suggested TTL of the record, in seconds.
+	TTL int `json:"ttl"`
+}
+
+// String returns the record in zone file format (e.g. `_acme-challenge.example.com. 3600 IN CNAME sub.auth.example.org.`).
+func (i Instructions) String() string {
+	return fmt.Sprintf("%s. %d IN %s %s", i.Name, i.TTL, i.Type, i.Target)
+}
+
+// suggestedCNAMETTL is the TTL suggested for the CNAME records, which aren't expected to change.
+const suggestedCNAMETTL = 3600
+
+// SetupInstructions returns the CNAME record delegating the ACME DNS-01 challenge of the given domain
+// to the account (see [Account.CNAMERecord]).
+// The apex, subdomains and wildcard domains are supported.
+func (a Account) SetupInstructions(domain string) Instructions {
+	name, target := a.CNAMERecord(domain)
+
+	return Instructions{
+		Domain: domain,
+		Name:   name,
+		Type:   "CNAME",
+		Target: target,
+		TTL:    suggestedCNAMETTL,
+	}
+}
+
+// Redacted returns a copy of the account with the password masked, safe to log.
+func (a Account) Redacted() Account {
+	if a.Password != "" {
+		a.Password = redactedPassword
+	}
+
+	return a
+}
+
+// String formats the account with the password masked (see [Account.Redacted]),
+// so that formatting or logging an account doesn't expose it.
+// The JSON and YAML serializations are unaffected.
+func (a Account) String() string {
+	// The conversion drops the String method, avoiding an infinite recursion.
+	type account Account
+
+	return fmt.Sprintf("%+v", account(a.Redacted()))
+}
+
+// Equal reports whether the account has the same fields as the other account.
+// A nil and an empty AllowFrom are considered equal.
+func (a Account) Equal(other Account) bool {
+	return len(a.Diff(other)) == 0
+}
+
+// Diff returns the names of the fields that differ between the account and the other account,
+// e.g. `[]string{"Password", "ServerURL"}`.
+// A nil and an empty AllowFrom are considered equal.
+func (a Account) Diff(other Account) []string {
+	var fields []string
+
+	if a.FullDomain != other.FullDomain {
+		fields = append(fields, "FullDomain")
+	}
+
+	if a.SubDomain != other.SubDomain {
+		fields = append(fields, "SubDomain")
+	}
+
+	if a.Username != other.Username {
+		fields = append(fields, "Username")
+	}
+
+	if a.Password != other.Password {
+		fields = append(fields, "Password")
+	}
+
+	if a.ServerURL != other.ServerURL {
+		fields = append(fields, "ServerURL")
+	}
+
+	if !slices.Equal(a.AllowFrom, other.AllowFrom) {
+		fields = append(fields, "AllowFrom")
+	}
+
+	if !a.CreatedAt.Equal(other.CreatedAt) {
+		fields = append(fields, "CreatedAt")
+	}
+
+	if !a.LastUsedAt.Equal(other.LastUsedAt) {
+		fields = append(fields, "LastUsedAt")
+	}
+
+	return fields
 }
@@ -0,0 +1,409 @@
+package goacmedns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAccount_Validate(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Account     Account
+		ExpectedErr error
+	}{
+		{
+			Name:    "valid account",
+			Account: testAcct,
+		},
+		{
+			Name: "valid account with server URL",
+			Account: Account{
+				SubDomain: "sub",
+				Username:  "user",
+				Password:  "secret",
+				ServerURL: "https://auth.acme-dns.io",
+			},
+		},
+		{
+			Name: "missing username",
+			Account: Account{
+				SubDomain: "sub",
+				Password:  "secret",
+			},
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name: "missing password",
+			Account: Account{
+				SubDomain: "sub",
+				Username:  "user",
+			},
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name: "missing subdomain",
+			Account: Account{
+				Username: "user",
+				Password: "secret",
+			},
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name: "invalid server URL",
+			Account: Account{
+				SubDomain: "sub",
+				Username:  "user",
+				Password:  "secret",
+				ServerURL: "https://auth acme-dns.io\n",
+			},
+			ExpectedErr: ErrInvalidAccount,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Account.Validate()
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
+
+func TestNewAccount(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Username    string
+		Password    string
+		SubDomain   string
+		FullDomain  string
+		ServerURL   string
+		ExpectedErr error
+	}{
+		{
+			Name:       "valid account",
+			Username:   "cpu",
+			Password:   "hunter2",
+			SubDomain:  "tossed",
+			FullDomain: "tossed.auth.acme-dns.io",
+			ServerURL:  "https://auth.acme-dns.io",
+		},
+		{
+			Name:        "missing username",
+			Password:    "hunter2",
+			SubDomain:   "tossed",
+			FullDomain:  "tossed.auth.acme-dns.io",
+			ServerURL:   "https://auth.acme-dns.io",
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name:        "missing password",
+			Username:    "cpu",
+			SubDomain:   "tossed",
+			FullDomain:  "tossed.auth.acme-dns.io",
+			ServerURL:   "https://auth.acme-dns.io",
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name:        "missing subdomain",
+			Username:    "cpu",
+			Password:    "hunter2",
+			FullDomain:  "tossed.auth.acme-dns.io",
+			ServerURL:   "https://auth.acme-dns.io",
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name:        "missing full domain",
+			Username:    "cpu",
+			Password:    "hunter2",
+			SubDomain:   "tossed",
+			ServerURL:   "https://auth.acme-dns.io",
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name:        "missing server URL",
+			Username:    "cpu",
+			Password:    "hunter2",
+			SubDomain:   "tossed",
+			FullDomain:  "tossed.auth.acme-dns.io",
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name:        "unparseable server URL",
+			Username:    "cpu",
+			Password:    "hunter2",
+			SubDomain:   "tossed",
+			FullDomain:  "tossed.auth.acme-dns.io",
+			ServerURL:   "https://auth acme-dns.io\n",
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name:        "relative server URL",
+			Username:    "cpu",
+			Password:    "hunter2",
+			SubDomain:   "tossed",
+			FullDomain:  "tossed.auth.acme-dns.io",
+			ServerURL:   "auth.acme-dns.io",
+			ExpectedErr: ErrInvalidAccount,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			acct, err := NewAccount(tc.Username, tc.Password, tc.SubDomain, tc.FullDomain, tc.ServerURL)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			if tc.ExpectedErr != nil {
+				if !reflect.DeepEqual(acct, Account{}) {
+					t.Errorf("expected a zero account on error, got %+v", acct)
+				}
+
+				return
+			}
+
+			expected := Account{
+				FullDomain: tc.FullDomain,
+				SubDomain:  tc.SubDomain,
+				Username:   tc.Username,
+				Password:   tc.Password,
+				ServerURL:  tc.ServerURL,
+			}
+
+			if !reflect.DeepEqual(acct, expected) {
+				t.Errorf("expected account %+v, got %+v", expected, acct)
+			}
+		})
+	}
+}
+
+func TestAccount_CNAMERecord(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Domain         string
+		FullDomain     string
+		ExpectedName   string
+		ExpectedTarget string
+	}{
+		{
+			Name:           "without trailing dots",
+			Domain:         "example.com",
+			FullDomain:     "sub.auth.example.org",
+			ExpectedName:   "_acme-challenge.example.com",
+			ExpectedTarget: "sub.auth.example.org.",
+		},
+		{
+			Name:           "with trailing dots",
+			Domain:         "example.com.",
+			FullDomain:     "sub.auth.example.org.",
+			ExpectedName:   "_acme-challenge.example.com",
+			ExpectedTarget: "sub.auth.example.org.",
+		},
+		{
+			Name:           "wildcard",
+			Domain:         "*.example.com",
+			FullDomain:     "sub.auth.example.org",
+			ExpectedName:   "_acme-challenge.example.com",
+			ExpectedTarget: "sub.auth.example.org.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			name, target := Account{FullDomain: tc.FullDomain}.CNAMERecord(tc.Domain)
+
+			if name != tc.ExpectedName {
+				t.Errorf("expected name %q, got %q", tc.ExpectedName, name)
+			}
+
+			if target != tc.ExpectedTarget {
+				t.Errorf("expected target %q, got %q", tc.ExpectedTarget, target)
+			}
+		})
+	}
+}
+
+func TestAccount_SetupInstructions(t *testing.T) {
+	acct := Account{FullDomain: "sub.auth.example.org"}
+
+	testCases := []struct {
+		Name     string
+		Domain   string
+		Expected Instructions
+	}{
+		{
+			Name:   "apex",
+			Domain: "example.com",
+			Expected: Instructions{
+				Domain: "example.com",
+				Name:   "_acme-challenge.example.com",
+				Type:   "CNAME",
+				Target: "sub.auth.example.org.",
+				TTL:    3600,
+			},
+		},
+		{
+			Name:   "subdomain",
+			Domain: "www.example.com",
+			Expected: Instructions{
+				Domain: "www.example.com",
+				Name:   "_acme-challenge.www.example.com",
+				Type:   "CNAME",
+				Target: "sub.auth.example.org.",
+				TTL:    3600,
+			},
+		},
+		{
+			Name:   "wildcard",
+			Domain: "*.example.com",
+			Expected: Instructions{
+				Domain: "*.example.com",
+				Name:   "_acme-challenge.example.com",
+				Type:   "CNAME",
+				Target: "sub.auth.example.org.",
+				TTL:    3600,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			instructions := acct.SetupInstructions(tc.Domain)
+
+			if instructions != tc.Expected {
+				t.Errorf("expected instructions %#v, got %#v", tc.Expected, instructions)
+			}
+		})
+	}
+}
+
+func TestInstructions_String(t *testing.T) {
+	instructions := Account{FullDomain: "sub.auth.example.org"}.SetupInstructions("example.com")
+
+	expected := "_acme-challenge.example.com. 3600 IN CNAME sub.auth.example.org."
+	if s := instructions.String(); s != expected {
+		t.Errorf("expected %q, got %q", expected, s)
+	}
+}
+
+func TestAccount_String(t *testing.T) {
+	for _, format := range []string{"%v", "%+v", "%s"} {
+		formatted := fmt.Sprintf(format, testAcct)
+
+		if strings.Contains(formatted, testAcct.Password) {
+			t.Errorf("expected the password to be hidden with %q, got %s", format, formatted)
+		}
+
+		if !strings.Contains(formatted, testAcct.Username) || !strings.Contains(formatted, redactedPassword) {
+			t.Errorf("expected the username and a masked password with %q, got %s", format, formatted)
+		}
+	}
+
+	// Accounts nested in other values are redacted too.
+	formatted := fmt.Sprint(map[string]Account{"example.com": testAcct})
+	if strings.Contains(formatted, testAcct.Password) {
+		t.Errorf("expected the password to be hidden, got %s", formatted)
+	}
+
+	raw, err := json.Marshal(testAcct)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling account: %v", err)
+	}
+
+	if !strings.Contains(string(raw), `"password":"`+testAcct.Password+`"`) {
+		t.Errorf("expected the password in the JSON, got %s", raw)
+	}
+}
+
+func TestAccount_Redacted(t *testing.T) {
+	redacted := testAcct.Redacted()
+
+	if redacted.Password != redactedPassword {
+		t.Errorf("expected password %q, got %q", redactedPassword, redacted.Password)
+	}
+
+	if diff := redacted.Diff(testAcct); !reflect.DeepEqual(diff, []string{"Password"}) {
+		t.Errorf("expected only the password to differ, got %v", diff)
+	}
+
+	if empty := (Account{}).Redacted(); empty.Password != "" {
+		t.Errorf("expected an empty password to be kept empty, got %q", empty.Password)
+	}
+}
+
+func TestAccount_Diff(t *testing.T) {
+	acct := testAcct
+	acct.ServerURL = "https://auth.acme-dns.io"
+
+	withPassword := acct
+	withPassword.Password = "rotated"
+
+	legacy := acct
+	legacy.ServerURL = ""
+
+	withAllowFrom := acct
+	withAllowFrom.AllowFrom = []string{"192.168.100.1/24"}
+
+	emptyAllowFrom := acct
+	emptyAllowFrom.AllowFrom = []string{}
+
+	testCases := []struct {
+		Name         string
+		Account      Account
+		Other        Account
+		ExpectedDiff []string
+	}{
+		{
+			Name:    "equal",
+			Account: acct,
+			Other:   acct,
+		},
+		{
+			Name:         "single field different",
+			Account:      acct,
+			Other:        withPassword,
+			ExpectedDiff: []string{"Password"},
+		},
+		{
+			Name:         "legacy",
+			Account:      legacy,
+			Other:        acct,
+			ExpectedDiff: []string{"ServerURL"},
+		},
+		{
+			Name:         "several fields different",
+			Account:      legacy,
+			Other:        withPassword,
+			ExpectedDiff: []string{"Password", "ServerURL"},
+		},
+		{
+			Name:         "allow from",
+			Account:      acct,
+			Other:        withAllowFrom,
+			ExpectedDiff: []string{"AllowFrom"},
+		},
+		{
+			Name:    "nil and empty allow from",
+			Account: acct,
+			Other:   emptyAllowFrom,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			diff := tc.Account.Diff(tc.Other)
+			if !reflect.DeepEqual(diff, tc.ExpectedDiff) {
+				t.Errorf("expected diff %v, got %v", tc.ExpectedDiff, diff)
+			}
+
+			if equal := tc.Account.Equal(tc.Other); equal != (len(tc.ExpectedDiff) == 0) {
+				t.Errorf("expected Equal to be %t, got %t", len(tc.ExpectedDiff) == 0, equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package goacmedns
+
+import "time"
+
+// BackoffStrategy computes the wait between the retries of [WithRetries].
+type BackoffStrategy interface {
+	// NextDelay returns the wait before the retry following the given attempt (starting at 0).
+	NextDelay(attempt int) time.Duration
+}
+
+// BackoffFunc is an adapter to use a function as a [BackoffStrategy].
+type BackoffFunc func(attempt int) time.Duration
+
+// NextDelay calls f(attempt).
+func (f BackoffFunc) NextDelay(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// WithBackoffStrategy sets the strategy computing the wait between the retries of [WithRetries],
+// in place of the exponential backoff from the interval given to [WithRetries].
+// A nil strategy restores the default exponential backoff.
+func WithBackoffStrategy(strategy BackoffStrategy) Option {
+	return func(c *Client) {
+		if c != nil {
+			c.backoffStrategy = strategy
+		}
+	}
+}
+
+// ConstantBackoff returns a [BackoffStrategy] waiting the same interval before each retry.
+func ConstantBackoff(interval time.Duration) BackoffStrategy {
+	return BackoffFunc(func(int) time.Duration {
+		return interval
+	})
+}
+
+// LinearBackoff returns a [BackoffStrategy] waiting `interval` before the first retry,
+// and one more `interval` before each of the next ones (up to 1 minute).
+func LinearBackoff(interval time.Duration) BackoffStrategy {
+	return BackoffFunc(func(attempt int) time.Duration {
+		if attempt >= int(maxRetryInterval/max(interval, 1)) {
+			return maxRetryInterval
+		}
+
+		return min(interval*time.Duration(attempt+1), maxRetryInterval)
+	})
+}
+
+// ExponentialBackoff returns a [BackoffStrategy] waiting `interval` before the first retry,
+// and doubling the wait before each of the next ones (up to 1 minute).
+// It is the default strategy of [WithRetries].
+func ExponentialBackoff(interval time.Duration) BackoffStrategy {
+	return BackoffFunc(func(attempt int) time.Duration {
+		return backoff(interval, attempt)
+	})
+}
@@ -0,0 +1,80 @@
+package goacmedns
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBackoffStrategy(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Strategy       BackoffStrategy
+		ExpectedDelays []time.Duration
+	}{
+		{
+			Name:           "constant",
+			Strategy:       ConstantBackoff(2 * time.Second),
+			ExpectedDelays: []time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second, 2 * time.Second},
+		},
+		{
+			Name:           "linear",
+			Strategy:       LinearBackoff(20 * time.Second),
+			ExpectedDelays: []time.Duration{20 * time.Second, 40 * time.Second, time.Minute, time.Minute},
+		},
+		{
+			Name:           "exponential",
+			Strategy:       ExponentialBackoff(10 * time.Second),
+			ExpectedDelays: []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute},
+		},
+		{
+			Name:           "func",
+			Strategy:       BackoffFunc(func(attempt int) time.Duration { return time.Duration(attempt) * time.Millisecond }),
+			ExpectedDelays: []time.Duration{0, time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var delays []time.Duration
+
+			for attempt := range len(tc.ExpectedDelays) {
+				delays = append(delays, tc.Strategy.NextDelay(attempt))
+			}
+
+			if !reflect.DeepEqual(delays, tc.ExpectedDelays) {
+				t.Errorf("expected delays %v, got %v", tc.ExpectedDelays, delays)
+			}
+		})
+	}
+}
+
+func TestLinearBackoff_overflow(t *testing.T) {
+	if delay := LinearBackoff(time.Hour).NextDelay(1 << 40); delay != time.Minute {
+		t.Errorf("expected delay %s, got %s", time.Minute, delay)
+	}
+}
+
+func TestWithBackoffStrategy(t *testing.T) {
+	clock := newFakeClock()
+
+	handler, calls := newFlakyHandler(t, 10)
+
+	// The interval of WithRetries is ignored.
+	client, mux := setupTest(t, WithBackoffStrategy(ConstantBackoff(5*time.Second)), WithRetries(3, time.Second), WithClock(clock))
+	mux.HandleFunc("/register", handler)
+
+	_, err := client.RegisterAccount(context.Background(), nil)
+	assertStatus(t, err, http.StatusServiceUnavailable)
+
+	if *calls != 4 {
+		t.Errorf("expected 4 calls, got %d", *calls)
+	}
+
+	expected := []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second}
+	if !reflect.DeepEqual(clock.waits, expected) {
+		t.Errorf("expected waits %v, got %v", expected, clock.waits)
+	}
+}
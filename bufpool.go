@@ -0,0 +1,40 @@
+package goacmedns
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which the buffers aren't returned to the pool,
+// so that an unusually large payload doesn't stay allocated.
+const maxPooledBufferSize = 64 << 10
+
+// bufferPool holds the buffers used to encode the JSON request bodies, reused across requests
+// to save the allocations of the buffers and of their growth.
+var bufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// encodeJSON encodes the payload as JSON into a pooled buffer, and returns a copy of the encoded bytes.
+// The body is copied out of the buffer, so that the buffer is reused right away,
+// while the retried requests can still re-read the body (see [http.Request.GetBody]):
+// each request still allocates its body once, at its exact size.
+// The body isn't sent from the pooled buffer, as the transport may still read it after the response is returned.
+func encodeJSON(payload any) ([]byte, error) {
+	buf, _ := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	err := json.NewEncoder(buf).Encode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(buf.Bytes()), nil
+}
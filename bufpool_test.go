@@ -0,0 +1,77 @@
+package goacmedns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestClient_newRequest_pooledBody(t *testing.T) {
+	client, err := NewClient("https://auth.example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	first, err := client.newRequest(ctx, http.MethodPost, client.endpoint("update"), nil, &Update{SubDomain: "first", Txt: "first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reuses the buffer of the first request.
+	_, err = client.newRequest(ctx, http.MethodPost, client.endpoint("update"), nil, &Update{SubDomain: "second", Txt: "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"subdomain":"first","txt":"first"}` + "\n"
+
+	for range 2 {
+		// Read like a retry.
+		body, err := first.GetBody()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, _ := io.ReadAll(body)
+		if string(data) != expected {
+			t.Errorf("expected body %q, got %q", expected, data)
+		}
+	}
+}
+
+// BenchmarkEncodeJSON compares the pooled encoding with a fresh buffer per request:
+// both return a body owned by the request, the pooled one only allocates the copy of the body.
+func BenchmarkEncodeJSON(b *testing.B) {
+	payload := &Update{SubDomain: testAcct.SubDomain, Txt: "___validation_token_received_from_the_ca___"}
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for range b.N {
+			_, err := encodeJSON(payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for range b.N {
+			buf := new(bytes.Buffer)
+
+			err := json.NewEncoder(buf).Encode(payload)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			_ = buf.Bytes()
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// maxConcurrentRequests is the size of the worker pool used by the bulk operations.
+const maxConcurrentRequests = 4
+
+// RegisterAccounts registers a new account for each of the domains, with the same allowFrom networks.
+// The registrations are done concurrently by a bounded pool of workers.
+// It returns the successfully registered accounts by domain,
+// and an aggregated error listing the domains whose registration failed.
+// Each registration is made with the context of its domain (see [WithDomainContext]).
+// When the context is canceled, no new registration is started.
+func (c *Client) RegisterAccounts(ctx context.Context, domains, allowFrom []string) (map[string]Account, error) {
+	var (
+		mu       sync.Mutex
+		accounts = make(map[string]Account)
+		errs     []error
+	)
+
+	forEach(ctx, domains, func(domain string) {
+		acct, err := c.RegisterAccount(WithDomainContext(ctx, domain), allowFrom)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", domain, err))
+
+			return
+		}
+
+		accounts[domain] = acct
+	})
+
+	if ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+
+	return accounts, errors.Join(errs...)
+}
+
+// UpdateTXTRecords sets the TXT values of the account, e.g. the two challenges of a certificate
+// for both a domain and its wildcard, which share the same ACME-DNS subdomain.
+// ACME-DNS keeps only the last two values sent for a subdomain:
+// the values are sent sequentially, in order, so that the last two values are the ones kept by the server.
+// All the values are sent even if some updates fail, and an aggregated error lists the failed ones.
+// When the context is canceled, no new update is sent.
+func (c *Client) UpdateTXTRecords(ctx context.Context, account Account, values []string) error {
+	var errs []error
+
+	for i, value := range values {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+
+			break
+		}
+
+		err := c.updateTXTRecord(ctx, account, value, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("value %d: %w", i, err))
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return fmt.Errorf("failed to update TXT records: %w", err)
+	}
+
+	return nil
+}
+
+// TXTUpdate is a TXT record value to set for an account (see [Client.UpdateTXTRecordsForAccounts]).
+type TXTUpdate struct {
+	Account Account
+	Value   string
+}
+
+// UpdateTXTRecordsForAccounts sets the TXT values of several accounts, e.g. the challenges of a renewal run.
+// The accounts are updated concurrently by a bounded pool of workers,
+// while the values of the same subdomain are sent sequentially, in order, like with [Client.UpdateTXTRecords].
+// All the values are sent even if some updates fail, and an aggregated error lists the failed ones by subdomain.
+// When the context is canceled, no new update is sent.
+func (c *Client) UpdateTXTRecordsForAccounts(ctx context.Context, updates []TXTUpdate) error {
+	var (
+		subdomains  []string
+		bySubdomain = make(map[string][]TXTUpdate)
+	)
+
+	for _, update := range updates {
+		subdomain := update.Account.SubDomain
+
+		if _, ok := bySubdomain[subdomain]; !ok {
+			subdomains = append(subdomains, subdomain)
+		}
+
+		bySubdomain[subdomain] = append(bySubdomain[subdomain], update)
+	}
+
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+
+	forEach(ctx, subdomains, func(subdomain string) {
+		for _, update := range bySubdomain[subdomain] {
+			if ctx.Err() != nil {
+				return
+			}
+
+			err := c.updateTXTRecord(ctx, update.Account, update.Value, nil)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", subdomain, err))
+				mu.Unlock()
+			}
+		}
+	})
+
+	if ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return fmt.Errorf("failed to update TXT records: %w", err)
+	}
+
+	return nil
+}
+
+// forEach calls fn for each distinct item, using a bounded pool of workers.
+// It stops dispatching the items when the context is done, and waits for the running calls to return.
+func forEach[T comparable](ctx context.Context, items []T, fn func(item T)) {
+	jobs := make(chan T)
+
+	var wg sync.WaitGroup
+
+	for range min(maxConcurrentRequests, len(items)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for item := range jobs {
+				fn(item)
+			}
+		}()
+	}
+
+	seen := make(map[T]struct{}, len(items))
+
+dispatch:
+	for _, item := range items {
+		if _, ok := seen[item]; ok {
+			continue
+		}
+
+		seen[item] = struct{}{}
+
+		select {
+		case jobs <- item:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+
+	close(jobs)
+
+	wg.Wait()
+}
@@ -0,0 +1,332 @@
+package goacmedns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newConcurrentRegHandler(running, maxRunning, calls *atomic.Int32, failEvery int32) http.HandlerFunc {
+	return func(resp http.ResponseWriter, _ *http.Request) {
+		current := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			highest := maxRunning.Load()
+			if current <= highest || maxRunning.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+
+		if n := calls.Add(1); failEvery > 0 && n%failEvery == 0 {
+			errHandler(resp, nil)
+
+			return
+		}
+
+		resp.WriteHeader(http.StatusCreated)
+
+		newRegBody, _ := json.Marshal(testAcct)
+		_, _ = resp.Write(newRegBody)
+	}
+}
+
+func TestClient_RegisterAccounts(t *testing.T) {
+	var running, maxRunning, calls atomic.Int32
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/register", newConcurrentRegHandler(&running, &maxRunning, &calls, 0))
+
+	domains := []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com", "e.example.com", "f.example.com", "a.example.com"}
+
+	accounts, err := client.RegisterAccounts(context.Background(), domains, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(accounts) != 6 {
+		t.Errorf("expected 6 accounts, got %d", len(accounts))
+	}
+
+	if calls.Load() != 6 {
+		t.Errorf("expected 6 registrations, got %d", calls.Load())
+	}
+
+	if maxRunning.Load() > maxConcurrentRequests {
+		t.Errorf("expected at most %d concurrent registrations, got %d", maxConcurrentRequests, maxRunning.Load())
+	}
+
+	if maxRunning.Load() < 2 {
+		t.Errorf("expected concurrent registrations, got %d", maxRunning.Load())
+	}
+}
+
+func TestClient_RegisterAccounts_errors(t *testing.T) {
+	var running, maxRunning, calls atomic.Int32
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/register", newConcurrentRegHandler(&running, &maxRunning, &calls, 2))
+
+	domains := []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com"}
+
+	accounts, err := client.RegisterAccounts(context.Background(), domains, nil)
+
+	var cErr *ClientError
+	if !errors.As(err, &cErr) {
+		t.Fatalf("expected aggregated ClientError, got %v", err)
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 2 {
+		t.Errorf("expected 2 aggregated errors, got %v", err)
+	}
+
+	if len(accounts) != 2 {
+		t.Errorf("expected 2 accounts, got %d", len(accounts))
+	}
+}
+
+func TestClient_RegisterAccounts_canceled(t *testing.T) {
+	var running, maxRunning, calls atomic.Int32
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/register", newConcurrentRegHandler(&running, &maxRunning, &calls, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.RegisterAccounts(ctx, []string{"a.example.com", "b.example.com"}, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func newTXTValuesHandler(t *testing.T, values *[]string, failValue string) http.HandlerFunc {
+	t.Helper()
+
+	return func(resp http.ResponseWriter, req *http.Request) {
+		var updateReq Update
+
+		err := json.NewDecoder(req.Body).Decode(&updateReq)
+		if err != nil {
+			t.Errorf("error decoding request body JSON: %v", err)
+		}
+
+		*values = append(*values, updateReq.Txt)
+
+		if updateReq.Txt == failValue {
+			errHandler(resp, req)
+
+			return
+		}
+
+		resp.WriteHeader(http.StatusOK)
+		_, _ = resp.Write([]byte(`{}`))
+	}
+}
+
+func TestClient_UpdateTXTRecords(t *testing.T) {
+	var values []string
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/update", newTXTValuesHandler(t, &values, ""))
+
+	expected := []string{"wildcard-challenge", "base-challenge"}
+
+	err := client.UpdateTXTRecords(context.Background(), testAcct, expected)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT records: %v", err)
+	}
+
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v to be sent in order, got %v", expected, values)
+	}
+}
+
+func TestClient_UpdateTXTRecords_errors(t *testing.T) {
+	var values []string
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/update", newTXTValuesHandler(t, &values, "wildcard-challenge"))
+
+	expected := []string{"wildcard-challenge", "base-challenge"}
+
+	err := client.UpdateTXTRecords(context.Background(), testAcct, expected)
+	assertStatus(t, err, http.StatusBadRequest)
+
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected all the values %v to be sent, got %v", expected, values)
+	}
+}
+
+func TestClient_UpdateTXTRecords_canceled(t *testing.T) {
+	var values []string
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/update", newTXTValuesHandler(t, &values, ""))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.UpdateTXTRecords(ctx, testAcct, []string{"wildcard-challenge", "base-challenge"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	if len(values) != 0 {
+		t.Errorf("expected no value to be sent, got %v", values)
+	}
+}
+
+// newConcurrentTXTHandler records the TXT values sent by subdomain, and the highest number of concurrent updates.
+// The updates setting the failValue are rejected.
+func newConcurrentTXTHandler(t *testing.T, values map[string][]string, mu *sync.Mutex, maxRunning *atomic.Int32, failValue string) http.HandlerFunc {
+	t.Helper()
+
+	var running atomic.Int32
+
+	return func(resp http.ResponseWriter, req *http.Request) {
+		current := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			highest := maxRunning.Load()
+			if current <= highest || maxRunning.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+
+		var updateReq Update
+
+		err := json.NewDecoder(req.Body).Decode(&updateReq)
+		if err != nil {
+			t.Errorf("error decoding request body JSON: %v", err)
+		}
+
+		mu.Lock()
+		values[updateReq.SubDomain] = append(values[updateReq.SubDomain], updateReq.Txt)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		if updateReq.Txt == failValue {
+			errHandler(resp, req)
+
+			return
+		}
+
+		resp.WriteHeader(http.StatusOK)
+		_, _ = resp.Write([]byte(`{}`))
+	}
+}
+
+func TestClient_UpdateTXTRecordsForAccounts(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		maxRunning atomic.Int32
+		values     = make(map[string][]string)
+	)
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/update", newConcurrentTXTHandler(t, values, &mu, &maxRunning, ""))
+
+	var updates []TXTUpdate
+
+	expected := make(map[string][]string)
+
+	for i := range 10 {
+		acct := testAcct
+		acct.SubDomain = fmt.Sprintf("sub%d", i)
+
+		for _, value := range []string{"wildcard-challenge", "base-challenge"} {
+			updates = append(updates, TXTUpdate{Account: acct, Value: value})
+			expected[acct.SubDomain] = append(expected[acct.SubDomain], value)
+		}
+	}
+
+	err := client.UpdateTXTRecordsForAccounts(context.Background(), updates)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT records: %v", err)
+	}
+
+	// The values of each subdomain are sent in order.
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+
+	if highest := maxRunning.Load(); highest > maxConcurrentRequests || highest < 2 {
+		t.Errorf("expected between 2 and %d concurrent updates, got %d", maxConcurrentRequests, highest)
+	}
+}
+
+func TestClient_UpdateTXTRecordsForAccounts_errors(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		maxRunning atomic.Int32
+		values     = make(map[string][]string)
+	)
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/update", newConcurrentTXTHandler(t, values, &mu, &maxRunning, "invalid"))
+
+	first, second, third := testAcct, testAcct, testAcct
+	first.SubDomain = "first"
+	second.SubDomain = "second"
+	third.SubDomain = "third"
+
+	err := client.UpdateTXTRecordsForAccounts(context.Background(), []TXTUpdate{
+		{Account: first, Value: "invalid"},
+		{Account: first, Value: "valid"},
+		{Account: second, Value: "valid"},
+		{Account: third, Value: "invalid"},
+	})
+	assertStatus(t, err, http.StatusBadRequest)
+
+	for _, subdomain := range []string{"first", "third"} {
+		if err == nil || !strings.Contains(err.Error(), subdomain+": ") {
+			t.Errorf("expected the error to list the subdomain %q, got %v", subdomain, err)
+		}
+	}
+
+	if err != nil && strings.Contains(err.Error(), "second: ") {
+		t.Errorf("expected the error not to list the subdomain %q, got %v", "second", err)
+	}
+
+	// All the values are sent.
+	expected := map[string][]string{"first": {"invalid", "valid"}, "second": {"valid"}, "third": {"invalid"}}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected values %v, got %v", expected, values)
+	}
+}
+
+func TestClient_UpdateTXTRecordsForAccounts_canceled(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		maxRunning atomic.Int32
+		values     = make(map[string][]string)
+	)
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/update", newConcurrentTXTHandler(t, values, &mu, &maxRunning, ""))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.UpdateTXTRecordsForAccounts(ctx, []TXTUpdate{{Account: testAcct, Value: updateValue}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	if len(values) != 0 {
+		t.Errorf("expected no value to be sent, got %v", values)
+	}
+}
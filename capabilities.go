@@ -0,0 +1,79 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Capabilities describes the optional endpoints supported by an ACME-DNS server.
+type Capabilities struct {
+	// Health reports whether the server exposes the `/health` endpoint (see [Client.UpdateTXTRecordDryRun]).
+	Health bool
+	// RotatePassword reports whether the server exposes the `/rotate` endpoint (see [Client.RotatePassword]).
+	RotatePassword bool
+}
+
+// Capabilities probes the optional endpoints of the server, and reports which ones are supported.
+// The endpoints are probed with the method used by the client, without credentials,
+// so that nothing is modified on the server.
+// Like for the calls of the client (see [ErrUnsupportedByServer]),
+// an endpoint is considered supported unless the server responds with a 404 or a 405 status.
+func (c *Client) Capabilities(ctx context.Context) (Capabilities, error) {
+	var (
+		caps Capabilities
+		err  error
+	)
+
+	caps.Health, err = c.probe(ctx, http.MethodGet, c.paths.Health)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	caps.RotatePassword, err = c.probe(ctx, http.MethodPost, c.paths.Rotate)
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	return caps, nil
+}
+
+// doOptional sends a request to an optional endpoint like [Client.do],
+// wrapping the 404 and 405 responses with [ErrUnsupportedByServer].
+func (c *Client) doOptional(req *http.Request, result any) error {
+	_, err := c.do(req, result)
+
+	var cErr *ClientError
+	if errors.As(err, &cErr) && isUnsupportedStatus(cErr.HTTPStatus) {
+		return fmt.Errorf("%w: %w", ErrUnsupportedByServer, err)
+	}
+
+	return err
+}
+
+// probe reports whether the endpoint exists on the server.
+func (c *Client) probe(ctx context.Context, method, endpoint string) (bool, error) {
+	req, err := c.newRequest(ctx, method, c.endpoint(endpoint), nil, nil)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = c.do(req, nil)
+	if err == nil {
+		return true, nil
+	}
+
+	var cErr *ClientError
+	if !errors.As(err, &cErr) {
+		return false, fmt.Errorf("failed to probe %s endpoint: %w", endpoint, err)
+	}
+
+	return !isUnsupportedStatus(cErr.HTTPStatus), nil
+}
+
+// isUnsupportedStatus reports whether the status code of a response means that the endpoint isn't supported:
+// either the path (404) or the method (405) is unknown to the server.
+func isUnsupportedStatus(status int) bool {
+	return status == http.StatusNotFound || status == http.StatusMethodNotAllowed
+}
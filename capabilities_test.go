@@ -0,0 +1,125 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestClient_Capabilities(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Endpoints    map[string]http.HandlerFunc
+		ExpectedCaps Capabilities
+	}{
+		{
+			Name: "no optional endpoint",
+		},
+		{
+			Name: "health only",
+			Endpoints: map[string]http.HandlerFunc{
+				"/health": healthHandler,
+			},
+			ExpectedCaps: Capabilities{Health: true},
+		},
+		{
+			Name: "health and rotate",
+			Endpoints: map[string]http.HandlerFunc{
+				"/health": healthHandler,
+				"POST /rotate": func(resp http.ResponseWriter, _ *http.Request) {
+					resp.WriteHeader(http.StatusUnauthorized)
+				},
+			},
+			ExpectedCaps: Capabilities{Health: true, RotatePassword: true},
+		},
+		{
+			Name: "rotate without POST",
+			Endpoints: map[string]http.HandlerFunc{
+				"/health": healthHandler,
+				"GET /rotate": func(resp http.ResponseWriter, _ *http.Request) {
+					resp.WriteHeader(http.StatusNoContent)
+				},
+			},
+			ExpectedCaps: Capabilities{Health: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t)
+
+			for pattern, handler := range tc.Endpoints {
+				mux.HandleFunc(pattern, func(resp http.ResponseWriter, req *http.Request) {
+					if req.Header.Get("X-Api-Key") != "" {
+						t.Errorf("expected no credentials to be sent")
+					}
+
+					handler(resp, req)
+				})
+			}
+
+			caps, err := client.Capabilities(context.Background())
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if caps != tc.ExpectedCaps {
+				t.Errorf("expected capabilities %+v, got %+v", tc.ExpectedCaps, caps)
+			}
+		})
+	}
+}
+
+func TestClient_Capabilities_unreachable(t *testing.T) {
+	client, err := NewClient("http://127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Capabilities(context.Background())
+	if err == nil {
+		t.Error("expected an error, got nil")
+	}
+}
+
+func TestErrUnsupportedByServer(t *testing.T) {
+	client, mux := setupTest(t)
+	mux.HandleFunc("/update", http.NotFound)
+
+	err := client.UpdateTXTRecordDryRun(context.Background(), testAcct)
+	if !errors.Is(err, ErrUnsupportedByServer) {
+		t.Errorf("expected ErrUnsupportedByServer from the health endpoint, got %v", err)
+	}
+
+	assertStatus(t, err, http.StatusNotFound)
+
+	// The update endpoint isn't optional.
+	err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if errors.Is(err, ErrUnsupportedByServer) {
+		t.Errorf("expected a plain ClientError from the update endpoint, got %v", err)
+	}
+
+	assertStatus(t, err, http.StatusNotFound)
+}
+
+func TestClient_Capabilities_agreesWithCalls(t *testing.T) {
+	client, mux := setupTest(t)
+	mux.HandleFunc("GET /rotate", func(resp http.ResponseWriter, _ *http.Request) {
+		resp.WriteHeader(http.StatusNoContent)
+	})
+
+	caps, err := client.Capabilities(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	_, err = client.RotatePassword(context.Background(), testAcct)
+	if !errors.Is(err, ErrUnsupportedByServer) {
+		t.Errorf("expected ErrUnsupportedByServer, got %v", err)
+	}
+
+	if caps.RotatePassword {
+		t.Error("expected the rotate endpoint to be reported unsupported, like by RotatePassword")
+	}
+}
@@ -0,0 +1,81 @@
+package goacmedns
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidCertPin is returned by [NewClient] when the fingerprint provided with [WithCertPin] is invalid,
+// or when it can't be enforced because a custom client is provided with [WithHTTPClient].
+var ErrInvalidCertPin = errors.New("invalid certificate pin")
+
+// ErrCertPinMismatch is returned when the certificate presented by the server doesn't match the pin set with [WithCertPin].
+var ErrCertPinMismatch = errors.New("server certificate doesn't match the pinned fingerprint")
+
+// WithCertPin pins the leaf certificate of the server to the given SHA-256 fingerprint,
+// hex-encoded, optionally with colons (e.g. the output of `openssl x509 -noout -fingerprint -sha256`).
+// The connections to a server presenting another certificate are rejected with [ErrCertPinMismatch],
+// even if the certificate is signed by a trusted CA.
+// The usual verification of the certificate chain still applies (see [WithTLSConfig]).
+// [NewClient] returns an error wrapping [ErrInvalidCertPin] if the fingerprint is invalid,
+// or if a custom client is provided with [WithHTTPClient].
+func WithCertPin(sha256Fingerprint string) Option {
+	return func(c *Client) {
+		if c == nil {
+			return
+		}
+
+		pin, err := hex.DecodeString(strings.ReplaceAll(sha256Fingerprint, ":", ""))
+		if err != nil || len(pin) != sha256.Size {
+			c.optionErrs = append(c.optionErrs, fmt.Errorf("%w: %q is not a SHA-256 fingerprint", ErrInvalidCertPin, sha256Fingerprint))
+
+			return
+		}
+
+		c.certPin = pin
+	}
+}
+
+// applyCertPin installs the verification of the pinned certificate on the TLS configuration of the built-in transport,
+// after the other options (see [WithTLSConfig]) are applied.
+func (c *Client) applyCertPin() error {
+	if c.transport == nil || c.httpClient.Transport != c.transport {
+		return fmt.Errorf("%w: it can't be enforced with a custom HTTP client", ErrInvalidCertPin)
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.transport.TLSClientConfig != nil {
+		cfg = c.transport.TLSClientConfig.Clone()
+	}
+
+	// VerifyConnection is also called on the resumed sessions, unlike VerifyPeerCertificate,
+	// so a session established without the pin (e.g. through a shared ClientSessionCache) can't bypass it.
+	verify := cfg.VerifyConnection
+	pin := c.certPin
+
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return ErrCertPinMismatch
+		}
+
+		fingerprint := sha256.Sum256(cs.PeerCertificates[0].Raw)
+		if !bytes.Equal(fingerprint[:], pin) {
+			return fmt.Errorf("%w: got %X", ErrCertPinMismatch, fingerprint)
+		}
+
+		if verify != nil {
+			return verify(cs)
+		}
+
+		return nil
+	}
+
+	c.transport.TLSClientConfig = cfg
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package goacmedns
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWithCertPin(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(healthHandler))
+	t.Cleanup(server.Close)
+
+	fingerprint := sha256.Sum256(server.Certificate().Raw)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	testCases := []struct {
+		Name        string
+		Pin         string
+		ExpectedErr error
+	}{
+		{
+			Name: "matching pin",
+			Pin:  hex.EncodeToString(fingerprint[:]),
+		},
+		{
+			Name: "matching pin with colons",
+			Pin:  colonHex(fingerprint[:]),
+		},
+		{
+			Name:        "mismatching pin",
+			Pin:         strings.Repeat("00", sha256.Size),
+			ExpectedErr: ErrCertPinMismatch,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			// The pin is kept when the TLS configuration is set afterward.
+			client, err := NewClient(server.URL, WithCertPin(tc.Pin), WithTLSConfig(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = client.UpdateTXTRecordDryRun(context.Background(), testAcct)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
+
+func TestWithCertPin_resumedSession(t *testing.T) {
+	var resumed atomic.Bool
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(healthHandler))
+	server.TLS = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			resumed.Store(cs.DidResume)
+
+			return nil
+		},
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	// The session cache is shared with a client without pin.
+	tlsConfig := &tls.Config{RootCAs: roots, ClientSessionCache: tls.NewLRUClientSessionCache(1), MinVersion: tls.VersionTLS12}
+
+	unpinned := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	t.Cleanup(unpinned.CloseIdleConnections)
+
+	resp, err := unpinned.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The session ticket is received after the handshake, with the response.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	client, err := NewClient(server.URL, WithTLSConfig(tlsConfig), WithCertPin(strings.Repeat("00", sha256.Size)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.UpdateTXTRecordDryRun(context.Background(), testAcct)
+	if !errors.Is(err, ErrCertPinMismatch) {
+		t.Errorf("expected ErrCertPinMismatch, got %v", err)
+	}
+
+	if !resumed.Load() {
+		t.Error("expected the TLS session to be resumed")
+	}
+}
+
+func TestWithCertPin_invalid(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		Pin     string
+		Options []Option
+	}{
+		{Name: "not hex", Pin: "not a fingerprint"},
+		{Name: "not SHA-256", Pin: strings.Repeat("00", 20)},
+		{
+			Name:    "custom HTTP client",
+			Pin:     strings.Repeat("00", sha256.Size),
+			Options: []Option{WithHTTPClient(&http.Client{})},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := NewClient("https://auth.example.org", append(tc.Options, WithCertPin(tc.Pin))...)
+			if !errors.Is(err, ErrInvalidCertPin) {
+				t.Errorf("expected ErrInvalidCertPin, got %v", err)
+			}
+		})
+	}
+}
+
+// colonHex formats the bytes as colon-separated uppercase hex, like `openssl x509 -fingerprint`.
+func colonHex(b []byte) string {
+	parts := make([]string, len(b))
+	for i, v := range b {
+		parts[i] = fmt.Sprintf("%02X", v)
+	}
+
+	return strings.Join(parts, ":")
+}
@@ -3,19 +3,29 @@ package goacmedns
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"net"
 	"net/http"
 	"net/url"
 	"runtime"
+	"slices"
+	"strings"
 	"time"
+
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
 )
 
 // defaultTimeout is used for the httpClient Timeout settings.
 const defaultTimeout = 30 * time.Second
 
+// defaultMaxResponseBytes is the default maximum size of the response bodies read by the client.
+const defaultMaxResponseBytes = 1 << 20 // 1 MiB
+
 // ua is a custom user-agent identifier.
 const ua = "goacmedns"
 
@@ -27,6 +37,9 @@ func userAgent() string {
 
 type Register struct {
 	AllowFrom []string `json:"allowfrom"`
+	// SubDomain is the preferred subdomain requested during the registration.
+	// It is only supported by some ACME-DNS forks.
+	SubDomain string `json:"subdomain,omitempty"`
 }
 
 type Update struct {
@@ -34,6 +47,12 @@ type Update struct {
 	Txt       string `json:"txt"`
 }
 
+// UpdateResponse is the response of the server to a TXT record update (see [Client.UpdateTXTRecordResponse]).
+type UpdateResponse struct {
+	// Txt is the TXT record value stored by the server.
+	Txt string `json:"txt"`
+}
+
 // Storage is an interface describing the required functions for an ACME DNS Account storage mechanism.
 type Storage interface {
 	// Save will persist the [Account] data that has been [Storage.Put] so far
@@ -43,13 +62,20 @@ type Storage interface {
 	Put(ctx context.Context, domain string, account Account) error
 	// Fetch will retrieve an [Account] for the given domain from the storage.
 	// If the provided domain does not have an [Account] saved in the storage
-	// [storage.ErrDomainNotFound] will be returned
+	// an error wrapping [ErrDomainNotFound] (storage.ErrDomainNotFound) will be returned
 	Fetch(ctx context.Context, domain string) (Account, error)
 	// FetchAll retrieves all the [Account] objects from the storage and
 	// returns a map that has domain names as its keys and [Account] objects as values.
 	FetchAll(ctx context.Context) (map[string]Account, error)
 }
 
+// Iterable is an optional interface of the [Storage] implementations able to iterate over their accounts,
+// e.g. to range over large storages without loading all the accounts in a map with [Storage.FetchAll].
+type Iterable interface {
+	// All returns an iterator over the domains and their [Account] in the storage.
+	All(ctx context.Context) iter.Seq2[string, Account]
+}
+
 type Option func(c *Client)
 
 func WithHTTPClient(client *http.Client) Option {
@@ -60,15 +86,352 @@ func WithHTTPClient(client *http.Client) Option {
 	}
 }
 
+// WithRequireAllowFrom makes [Client.RegisterAccount] return [ErrAllowFromRequired]
+// when no allowFrom networks are provided, preventing the creation of unrestricted accounts.
+func WithRequireAllowFrom() Option {
+	return func(c *Client) {
+		if c != nil {
+			c.requireAllowFrom = true
+		}
+	}
+}
+
+// WithRequireHTTPS makes [NewClient] return an [ErrInsecureBaseURL] error when the base URL,
+// or the fallback URL (see [WithFallbackURL]), doesn't use the `https` scheme,
+// so that the API keys are never sent in plaintext.
+// The loopback hosts (e.g. `localhost`, `127.0.0.1`) are allowed over HTTP, for testing.
+func WithRequireHTTPS() Option {
+	return func(c *Client) {
+		if c != nil {
+			c.requireHTTPS = true
+		}
+	}
+}
+
+// WithoutEnvProxy disables the use of the proxy configured by the environment variables
+// (HTTP_PROXY, HTTPS_PROXY, NO_PROXY) by the built-in transport.
+// It has no effect when a custom client is provided with [WithHTTPClient].
+func WithoutEnvProxy() Option {
+	return func(c *Client) {
+		if c != nil && c.transport != nil {
+			c.transport.Proxy = nil
+		}
+	}
+}
+
+// WithProxy routes the requests of the built-in transport through the HTTP proxy at the given URL,
+// instead of the proxy configured by the environment variables.
+// [NewClient] returns an error wrapping [ErrInvalidProxyURL] if the URL is invalid.
+// It has no effect when a custom client is provided with [WithHTTPClient].
+func WithProxy(proxyURL string) Option {
+	return func(c *Client) {
+		if c == nil || c.transport == nil {
+			return
+		}
+
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			c.optionErrs = append(c.optionErrs, fmt.Errorf("%w: %w", ErrInvalidProxyURL, err))
+
+			return
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			c.optionErrs = append(c.optionErrs, fmt.Errorf("%w: %q", ErrInvalidProxyURL, proxyURL))
+
+			return
+		}
+
+		c.transport.Proxy = http.ProxyURL(u)
+	}
+}
+
+// WithTransportConfig tunes the connection pooling of the built-in transport,
+// e.g. to keep more idle connections when registering many accounts against the same server.
+// See [http.Transport.MaxIdleConns], [http.Transport.MaxIdleConnsPerHost] and [http.Transport.IdleConnTimeout].
+// It has no effect when a custom client is provided with [WithHTTPClient].
+func WithTransportConfig(maxIdleConns, maxIdleConnsPerHost int, idleTimeout time.Duration) Option {
+	return func(c *Client) {
+		if c != nil && c.transport != nil {
+			c.transport.MaxIdleConns = maxIdleConns
+			c.transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+			c.transport.IdleConnTimeout = idleTimeout
+		}
+	}
+}
+
+// WithFallbackURL sets the base URL of a secondary ACME-DNS server,
+// used when a request to the primary server fails with a network error or a 5xx response.
+// Like with [WithRetries], the registrations only fail over on connection failures and 503 responses.
+// The [Account.ServerURL] of the registered accounts is the URL of the server that served the registration.
+// [NewClient] returns an error wrapping [ErrInvalidBaseURL] if the URL is invalid.
+func WithFallbackURL(fallbackURL string) Option {
+	return func(c *Client) {
+		if c == nil {
+			return
+		}
+
+		endpoint, err := parseBaseURL(fallbackURL)
+		if err != nil {
+			c.optionErrs = append(c.optionErrs, fmt.Errorf("fallback URL: %w", err))
+
+			return
+		}
+
+		c.fallbackURL = endpoint
+	}
+}
+
+// WithServerURL sets the value stamped into the [Account.ServerURL] of the registered accounts,
+// instead of the base URL of the server that served the registration,
+// e.g. when the client reaches the server through a reverse proxy rewriting hosts.
+func WithServerURL(serverURL string) Option {
+	return func(c *Client) {
+		if c != nil {
+			c.serverURL = serverURL
+			c.skipServerURLStamp = false
+		}
+	}
+}
+
+// WithoutServerURLStamp leaves the [Account.ServerURL] of the registered accounts empty.
+func WithoutServerURLStamp() Option {
+	return func(c *Client) {
+		if c != nil {
+			c.serverURL = ""
+			c.skipServerURLStamp = true
+		}
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used by the built-in transport,
+// e.g. to trust a private CA or to present a client certificate.
+// It has no effect when a custom client is provided with [WithHTTPClient].
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) {
+		if c != nil && c.transport != nil {
+			c.transport.TLSClientConfig = cfg
+		}
+	}
+}
+
+// WithRequestIDFunc sets a function called for every request to generate the value of the `X-Request-Id` header,
+// allowing to correlate the client activity with the ACME-DNS server logs.
+// By default, no `X-Request-Id` header is sent.
+func WithRequestIDFunc(fn func() string) Option {
+	return func(c *Client) {
+		if c != nil {
+			c.requestID = fn
+		}
+	}
+}
+
+// WithLogger sets the [Logger] used to log the HTTP calls of the client.
+// By default, nothing is logged.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		if c != nil && logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// WithRequestHook sets a function called with every request before it is sent,
+// e.g. to start a tracing span or to record metrics.
+func WithRequestHook(hook func(*http.Request)) Option {
+	return func(c *Client) {
+		if c != nil {
+			c.requestHook = hook
+		}
+	}
+}
+
+// WithResponseHook sets a function called with every response received, including error responses,
+// e.g. to end a tracing span or to record metrics.
+// The response body must not be read by the hook.
+// It isn't called when no response is received (e.g. network errors).
+func WithResponseHook(hook func(*http.Response)) Option {
+	return func(c *Client) {
+		if c != nil {
+			c.responseHook = hook
+		}
+	}
+}
+
+// WithMaxResponseBytes sets the maximum size of the response bodies read by the client (1 MiB by default).
+// A successful response with a larger body results in an [ErrResponseTooLarge] error,
+// while the body of an error response is truncated to this size in the [ClientError].
+func WithMaxResponseBytes(n int64) Option {
+	return func(c *Client) {
+		if c != nil && n > 0 {
+			c.maxResponseBytes = n
+		}
+	}
+}
+
+// WithMaxRetryAfterWait sets the maximum total time spent waiting before retrying
+// the requests rate-limited by the server (429 responses with a `Retry-After` header).
+// The default is 1 minute. Zero disables the retries.
+func WithMaxRetryAfterWait(d time.Duration) Option {
+	return func(c *Client) {
+		if c != nil && d >= 0 {
+			c.maxRetryAfterWait = d
+		}
+	}
+}
+
+// WithCallTimeout bounds each call of the client (including its retries) with the given timeout,
+// independently of the deadline of the context passed by the caller.
+// It composes with the caller's context: the call can't last longer than the caller's deadline either.
+func WithCallTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if c != nil && d > 0 {
+			c.callTimeout = d
+		}
+	}
+}
+
+// WithBasicAuth sets the HTTP Basic Auth credentials sent with every request,
+// e.g. when the ACME-DNS server is behind a reverse proxy protecting the `/register` endpoint.
+// The credentials are sent in the `Authorization` header and don't interfere with the `X-Api-User`/`X-Api-Key` headers.
+func WithBasicAuth(user, pass string) Option {
+	return func(c *Client) {
+		if c != nil {
+			c.basicAuth = url.UserPassword(user, pass)
+		}
+	}
+}
+
+// WithDefaultHeaders sets static headers sent with every request, e.g. a tenant identifier required by a gateway.
+// The headers of [WithHeaderFunc] replace the default headers with the same name,
+// and the headers set by the client (e.g. `User-Agent`, `Content-Type`, `X-Api-Key`) take precedence over both.
+func WithDefaultHeaders(headers http.Header) Option {
+	return func(c *Client) {
+		if c != nil {
+			c.defaultHeaders = headers.Clone()
+		}
+	}
+}
+
+// WithHeaderFunc sets a function called for each request to provide extra headers,
+// e.g. short-lived tokens required by a proxy in front of the ACME-DNS server.
+// An error returned by the function aborts the request.
+// The headers are applied before the headers set by the client (e.g. `User-Agent`, `Content-Type`, `X-Api-Key`),
+// which take precedence over them.
+func WithHeaderFunc(fn func(ctx context.Context) (http.Header, error)) Option {
+	return func(c *Client) {
+		if c != nil {
+			c.headerFunc = fn
+		}
+	}
+}
+
+// WithResponseValidator sets a function validating the accounts returned by the server on registration,
+// e.g. to detect a misconfigured server early.
+// A non-nil error aborts the registration and is returned to the caller.
+// See [WithDefaultResponseValidator] for a validator of the critical fields.
+func WithResponseValidator(fn func(Account) error) Option {
+	return func(c *Client) {
+		if c != nil {
+			c.responseValidator = fn
+		}
+	}
+}
+
+// WithDefaultResponseValidator validates the accounts returned by the server on registration
+// with [ValidateRegisteredAccount] (see [WithResponseValidator]).
+func WithDefaultResponseValidator() Option {
+	return WithResponseValidator(ValidateRegisteredAccount)
+}
+
+// ValidateRegisteredAccount checks that an account returned by the server on registration
+// has all the fields required to update its TXT record and to set up the CNAME record:
+// the [Account.Validate] fields and the full domain.
+func ValidateRegisteredAccount(acct Account) error {
+	err := acct.Validate()
+	if err != nil {
+		return err
+	}
+
+	if acct.FullDomain == "" {
+		return fmt.Errorf("%w: missing fulldomain", ErrInvalidAccount)
+	}
+
+	return nil
+}
+
 type Client struct {
-	httpClient *http.Client
-	baseURL    *url.URL
+	httpClient  *http.Client
+	baseURL     *url.URL
+	fallbackURL *url.URL
+	paths       EndpointPaths
+	contentType ContentType
+
+	// serverURL overrides the URL stamped into the registered accounts (see [WithServerURL]).
+	serverURL          string
+	skipServerURLStamp bool
+
+	// transport is the built-in transport of the default httpClient.
+	transport *http.Transport
+
+	requireAllowFrom bool
+	requireHTTPS     bool
+	requestID        func() string
+	logger           Logger
+	requestHook      func(*http.Request)
+	responseHook     func(*http.Response)
+	metrics          MetricsRecorder
+	maxResponseBytes int64
+	strictDecoding   bool
+	callTimeout      time.Duration
+	basicAuth        *url.Userinfo
+	headerFunc       func(ctx context.Context) (http.Header, error)
+	defaultHeaders   http.Header
+	traceHeaders     []string
+	// maxRetryAfterWait caps the total time waited on `Retry-After` headers for a request.
+	maxRetryAfterWait time.Duration
+	// retries is the number of retries of the requests failing with server failures (see [WithRetries]).
+	retries       int
+	retryInterval time.Duration
+	// backoffStrategy replaces the exponential backoff from the retryInterval (see [WithBackoffStrategy]).
+	backoffStrategy BackoffStrategy
+
+	resolver     Resolver
+	nameserver   string
+	pollInterval time.Duration
+	clock        Clock
+
+	// responseValidator validates the accounts returned on registration (see [WithResponseValidator]).
+	responseValidator func(Account) error
+
+	// certPin is the SHA-256 fingerprint of the pinned server certificate (see [WithCertPin]).
+	certPin []byte
+
+	// limiter throttles the requests (see [WithRateLimit]).
+	limiter *rate.Limiter
+
+	// ensureGroup deduplicates the concurrent registrations of the same domain (see [Client.EnsureAccount]).
+	ensureGroup singleflight.Group
+
+	// optionErrs collects the errors of the invalid options, returned by [NewClient].
+	optionErrs []error
 }
 
 func NewClient(baseURL string, opts ...Option) (*Client, error) {
-	endpoint, err := url.Parse(baseURL)
+	endpoint, err := parseBaseURL(baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse base URL: %w", err)
+		return nil, err
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   defaultTimeout,
+			KeepAlive: defaultTimeout,
+		}).DialContext,
+		TLSHandshakeTimeout:   defaultTimeout,
+		ResponseHeaderTimeout: defaultTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
 	}
 
 	client := &Client{
@@ -76,51 +439,305 @@ func NewClient(baseURL string, opts ...Option) (*Client, error) {
 			CheckRedirect: nil,
 			Jar:           nil,
 			Timeout:       defaultTimeout,
-			Transport: &http.Transport{
-				Proxy: http.ProxyFromEnvironment,
-				DialContext: (&net.Dialer{
-					Timeout:   defaultTimeout,
-					KeepAlive: defaultTimeout,
-				}).DialContext,
-				TLSHandshakeTimeout:   defaultTimeout,
-				ResponseHeaderTimeout: defaultTimeout,
-				ExpectContinueTimeout: 1 * time.Second,
-			},
+			Transport:     transport,
 		},
-		baseURL: endpoint,
+		baseURL:           endpoint,
+		paths:             DefaultEndpointPaths(),
+		contentType:       ContentTypeJSON,
+		transport:         transport,
+		logger:            noopLogger{},
+		maxResponseBytes:  defaultMaxResponseBytes,
+		maxRetryAfterWait: defaultMaxRetryAfterWait,
+		resolver:          net.DefaultResolver,
+		pollInterval:      defaultPollInterval,
+		clock:             realClock{},
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
 
+	if client.requireHTTPS {
+		for _, u := range []*url.URL{client.baseURL, client.fallbackURL} {
+			if u != nil && !isSecureURL(u) {
+				client.optionErrs = append(client.optionErrs, fmt.Errorf("%w: %q", ErrInsecureBaseURL, u.Redacted()))
+			}
+		}
+	}
+
+	if client.certPin != nil {
+		err = client.applyCertPin()
+		if err != nil {
+			client.optionErrs = append(client.optionErrs, err)
+		}
+	}
+
+	if len(client.traceHeaders) > 0 {
+		client.applyTraceHeaderPropagation()
+	}
+
+	err = errors.Join(client.optionErrs...)
+	if err != nil {
+		return nil, err
+	}
+
 	return client, nil
 }
 
+// NewClientForAccount returns a [Client] targeting the server the account was registered with (its [Account.ServerURL]),
+// e.g. to update the TXT record of a stored account.
+// It returns an error wrapping [ErrMissingServerURL] if the account has no ServerURL (a legacy account):
+// the server URL must then be provided to [NewClient], or the account migrated (see `storage.File.Migrate`).
+func NewClientForAccount(account Account, opts ...Option) (*Client, error) {
+	if account.ServerURL == "" {
+		return nil, fmt.Errorf("%w: the account for %q predates the recording of the server URL", ErrMissingServerURL, account.FullDomain)
+	}
+
+	return NewClient(account.ServerURL, opts...)
+}
+
+// parseBaseURL parses and validates the base URL of an ACME-DNS server.
+// The trailing slashes of the path are removed, so that joining the endpoint paths is predictable.
+func parseBaseURL(baseURL string) (*url.URL, error) {
+	endpoint, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse base URL: %w", err)
+	}
+
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("%w: %q must be an absolute URL with a scheme and a host", ErrInvalidBaseURL, baseURL)
+	}
+
+	endpoint.Path = strings.TrimRight(endpoint.Path, "/")
+	endpoint.RawPath = strings.TrimRight(endpoint.RawPath, "/")
+
+	return endpoint, nil
+}
+
+// isSecureURL reports whether the URL uses HTTPS, or targets a loopback host.
+func isSecureURL(u *url.URL) bool {
+	if u.Scheme == "https" {
+		return true
+	}
+
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// Close closes the idle connections of the built-in transport.
+// It is a no-op when a custom client is provided with [WithHTTPClient].
+// The client can still be used after Close, new connections are opened as needed.
+func (c *Client) Close() {
+	if c.transport != nil && unwrapTransport(c.httpClient.Transport) == c.transport {
+		c.transport.CloseIdleConnections()
+	}
+}
+
+// ValidateAllowFrom checks that each allowFrom entry is a valid IPv4 or IPv6 CIDR network (e.g. `192.168.100.1/24`).
+// The returned error wraps [ErrInvalidAllowFrom] and identifies the first invalid entry.
+func ValidateAllowFrom(allowFrom []string) error {
+	for _, cidr := range allowFrom {
+		_, _, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("%w: %q", ErrInvalidAllowFrom, cidr)
+		}
+	}
+
+	return nil
+}
+
 func (c *Client) RegisterAccount(ctx context.Context, allowFrom []string) (Account, error) {
+	return c.RegisterAccountWithSubdomain(ctx, allowFrom, "")
+}
+
+// RegisterAccountWithSubdomain registers a new account requesting the given subdomain.
+// Requesting a subdomain is only supported by some ACME-DNS forks.
+// When the subdomain is empty, it behaves like [Client.RegisterAccount].
+func (c *Client) RegisterAccountWithSubdomain(ctx context.Context, allowFrom []string, subdomain string) (Account, error) {
+	if c.requireAllowFrom && len(allowFrom) == 0 {
+		return Account{}, ErrAllowFromRequired
+	}
+
+	err := ValidateAllowFrom(allowFrom)
+	if err != nil {
+		return Account{}, err
+	}
+
 	var register *Register
-	if len(allowFrom) > 0 {
-		register = &Register{AllowFrom: allowFrom}
+	if len(allowFrom) > 0 || subdomain != "" {
+		register = &Register{AllowFrom: allowFrom, SubDomain: subdomain}
 	}
 
-	req, err := newRequest(ctx, c.baseURL.JoinPath("register"), nil, register)
+	return c.register(ctx, register)
+}
+
+// RegisterAccountRaw registers a new account sending the given payload, marshaled to JSON, as the registration request body,
+// e.g. to send the extra fields supported by an ACME-DNS fork.
+// With [ContentTypeForm], the payload must be a [url.Values].
+// The response is decoded as a standard account, like with [Client.RegisterAccount].
+// The payload isn't checked: [WithRequireAllowFrom] and [ValidateAllowFrom] don't apply.
+func (c *Client) RegisterAccountRaw(ctx context.Context, payload any) (Account, error) {
+	return c.register(ctx, payload)
+}
+
+// register sends the registration request with the given payload (no body if nil), and returns the registered account.
+func (c *Client) register(ctx context.Context, payload any) (Account, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, c.endpoint(c.paths.Register), nil, payload)
 	if err != nil {
 		return Account{}, err
 	}
 
 	var acct Account
 
-	err = c.do(req, &acct)
+	// A duplicate registration would create another account.
+	served, err := c.do(nonIdempotent(req), &acct)
 	if err != nil {
 		return Account{}, fmt.Errorf("failed to register account: %w", err)
 	}
 
-	acct.ServerURL = c.baseURL.String()
+	acct.ServerURL = c.accountServerURL(served)
+	acct.CreatedAt = c.clock.Now().UTC()
+
+	if c.responseValidator != nil {
+		err = c.responseValidator(acct)
+		if err != nil {
+			return Account{}, fmt.Errorf("invalid registered account: %w", err)
+		}
+	}
 
 	return acct, nil
 }
 
+// accountServerURL returns the URL stamped into the accounts registered with the server at the served base URL.
+func (c *Client) accountServerURL(served *url.URL) string {
+	switch {
+	case c.skipServerURLStamp:
+		return ""
+	case c.serverURL != "":
+		return c.serverURL
+	default:
+		return served.String()
+	}
+}
+
 func (c *Client) UpdateTXTRecord(ctx context.Context, account Account, value string) error {
+	err := c.updateTXTRecord(ctx, account, value, nil)
+	if err != nil {
+		return fmt.Errorf("failed to update TXT record: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTXTRecordResponse updates the TXT record of the account like [Client.UpdateTXTRecord],
+// and returns the response of the server, to confirm the stored value.
+// Unlike [Client.UpdateTXTRecord], an error is returned if the server doesn't respond with a JSON body.
+func (c *Client) UpdateTXTRecordResponse(ctx context.Context, account Account, value string) (UpdateResponse, error) {
+	var resp UpdateResponse
+
+	err := c.updateTXTRecord(ctx, account, value, &resp)
+	if err != nil {
+		return UpdateResponse{}, fmt.Errorf("failed to update TXT record: %w", err)
+	}
+
+	return resp, nil
+}
+
+// ClearedTXTValue is the placeholder value set by [Client.ClearTXTRecord] in place of the challenge values.
+// It is accepted by ACME-DNS (43 characters of the base64url alphabet), but never matches an ACME challenge.
+const ClearedTXTValue = "___________________________________________"
+
+// ClearTXTRecord removes the challenge values of the TXT record of the account, e.g. after the validation.
+// ACME-DNS has no endpoint to delete the TXT record, and keeps the two most recent values:
+// the record is cleared by updating it twice with [ClearedTXTValue], replacing both values.
+// The TXT record then still exists, but no longer holds any challenge value.
+func (c *Client) ClearTXTRecord(ctx context.Context, account Account) error {
+	for range 2 {
+		err := c.updateTXTRecord(ctx, account, ClearedTXTValue, nil)
+		if err != nil {
+			return fmt.Errorf("failed to clear TXT record: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// UpdateTXTRecordWithCredentials updates the TXT record of the account like [Client.UpdateTXTRecord],
+// but authenticates with the given `username` and `password` instead of the credentials stored in the account,
+// e.g. when the credentials are being rotated.
+// The account isn't modified.
+func (c *Client) UpdateTXTRecordWithCredentials(ctx context.Context, account Account, username, password, value string) error {
+	account.Username = username
+	account.Password = password
+
+	return c.UpdateTXTRecord(ctx, account, value)
+}
+
+// RotatePassword asks the server to replace the password of the account, and returns the account with the new password.
+// The account given as argument isn't modified, the returned account must be stored in place of it.
+// Password rotation isn't part of the acme-dns API: it's only supported by the forks exposing a `/rotate` endpoint.
+// With the servers without support for it, the returned error wraps [ErrUnsupportedByServer] and the [ClientError].
+func (c *Client) RotatePassword(ctx context.Context, account Account) (Account, error) {
+	headers := map[string]string{
+		"X-Api-User": account.Username,
+		"X-Api-Key":  account.Password,
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.endpoint(c.paths.Rotate), headers, nil)
+	if err != nil {
+		return Account{}, err
+	}
+
+	var rotated Account
+
+	err = c.doOptional(req, &rotated)
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to rotate password: %w", err)
+	}
+
+	if rotated.Password == "" {
+		return Account{}, ErrMissingPassword
+	}
+
+	account.Password = rotated.Password
+
+	return account, nil
+}
+
+// ValidateAccount checks that the credentials of the account are still accepted by the server.
+// It sends an update with an empty TXT value: the server checks the credentials before rejecting the value,
+// so the TXT record of the account is left unchanged.
+// When the credentials are rejected (401 or 403 responses), the returned error wraps [ErrUnauthorized] and the [ClientError].
+func (c *Client) ValidateAccount(ctx context.Context, account Account) error {
+	err := c.updateTXTRecord(ctx, account, "", nil)
+	if err == nil {
+		return nil
+	}
+
+	var cErr *ClientError
+	if !errors.As(err, &cErr) {
+		return fmt.Errorf("failed to validate account: %w", err)
+	}
+
+	switch cErr.HTTPStatus {
+	case http.StatusBadRequest:
+		// The credentials were accepted, and the empty TXT value was rejected.
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrUnauthorized, cErr)
+	default:
+		return fmt.Errorf("failed to validate account: %w", cErr)
+	}
+}
+
+// updateTXTRecord sends the TXT record update, decoding the response into the result if not nil.
+func (c *Client) updateTXTRecord(ctx context.Context, account Account, value string, result any) error {
 	update := &Update{
 		SubDomain: account.SubDomain,
 		Txt:       value,
@@ -131,74 +748,310 @@ func (c *Client) UpdateTXTRecord(ctx context.Context, account Account, value str
 		"X-Api-Key":  account.Password,
 	}
 
-	req, err := newRequest(ctx, c.baseURL.JoinPath("update"), headers, update)
+	req, err := c.newRequest(ctx, http.MethodPost, c.endpoint(c.paths.Update), headers, update)
 	if err != nil {
 		return err
 	}
 
-	err = c.do(req, nil)
+	_, err = c.do(req, result)
+
+	return err
+}
+
+// UpdateTXTRecordDryRun performs the checks of [Client.UpdateTXTRecord] without updating the TXT record:
+// the account fields are validated with [Account.Validate], and the server reachability is checked with its health endpoint.
+// The credentials of the account aren't checked, as ACME-DNS has no endpoint to do so without a mutation.
+// With the servers without a health endpoint, the returned error wraps [ErrUnsupportedByServer].
+func (c *Client) UpdateTXTRecordDryRun(ctx context.Context, account Account) error {
+	err := account.Validate()
 	if err != nil {
-		return fmt.Errorf("failed to update TXT record: %w", err)
+		return err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, c.endpoint(c.paths.Health), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	err = c.doOptional(req, nil)
+	if err != nil {
+		return fmt.Errorf("failed to check server health: %w", err)
 	}
 
 	return nil
 }
 
-func (c *Client) do(req *http.Request, result any) error {
+// do sends the request and reads the response into result, retrying it (see [WithRetries])
+// and failing over to the fallback server (see [WithFallbackURL]) on server failures.
+// It returns the base URL of the server that served the request.
+func (c *Client) do(req *http.Request, result any) (*url.URL, error) {
+	if c.callTimeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), c.callTimeout)
+		defer cancel()
+
+		req = req.WithContext(ctx)
+	}
+
+	if c.metrics == nil {
+		return c.doWithRetries(req, result)
+	}
+
+	var status int
+
+	start := time.Now()
+
+	served, err := c.doWithRetries(withStatusRecorder(req, &status), result)
+
+	c.observe(req, status, start)
+
+	return served, err
+}
+
+// doWithFallback sends the request and reads the response into result,
+// failing over to the fallback server (see [WithFallbackURL]) on server failures.
+// It returns the base URL of the server that served the request.
+func (c *Client) doWithFallback(req *http.Request, result any) (*url.URL, error) {
+	err := c.doWithRetryAfter(req, result)
+	if c.fallbackURL == nil || !isRetryable(req, err) {
+		return c.baseURL, err
+	}
+
+	c.debugf(req.Context(), "failing over %s %s to %s: %v", req.Method, req.URL, c.fallbackURL, err)
+
+	req, err = c.fallbackRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.fallbackURL, c.doWithRetryAfter(req, result)
+}
+
+// doWithRetryAfter sends the request, retrying it while it is rate-limited, and reads the response into result.
+func (c *Client) doWithRetryAfter(req *http.Request, result any) error {
+	var waited time.Duration
+
+	for {
+		resp, err := c.send(req)
+		if err != nil {
+			return err
+		}
+
+		delay, ok := c.retryAfterDelay(resp, waited)
+		if !ok {
+			return c.readResponse(resp, result)
+		}
+
+		_ = resp.Body.Close()
+
+		c.debugf(req.Context(), "retrying %s %s in %s (%d)", req.Method, req.URL, delay, resp.StatusCode)
+
+		err = c.sleep(req.Context(), delay)
+		if err != nil {
+			return fmt.Errorf("failed to wait before retrying: %w", err)
+		}
+
+		waited += delay
+
+		req, err = c.cloneRequest(req)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// isServerFailure reports whether the error of a request is a server failure, calling for a retry or the fallback server:
+// a network error or a 5xx response, while the context isn't done.
+func isServerFailure(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	var cErr *ClientError
+	if errors.As(err, &cErr) {
+		return cErr.HTTPStatus >= http.StatusInternalServerError
+	}
+
+	var urlErr *url.Error
+
+	return errors.As(err, &urlErr)
+}
+
+// fallbackRequest returns a copy of the request sent to the fallback server.
+func (c *Client) fallbackRequest(req *http.Request) (*http.Request, error) {
+	clone, err := c.cloneRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parsed from its string like in newRequest, to get an absolute path.
+	endpoint, err := url.Parse(c.fallbackURL.JoinPath(strings.TrimPrefix(req.URL.Path, c.baseURL.Path)).String())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create fallback request: %w", err)
+	}
+
+	endpoint.RawQuery = req.URL.RawQuery
+
+	clone.URL = endpoint
+	clone.Host = endpoint.Host
+
+	return clone, nil
+}
+
+// send sends the request, calling the hooks and logging the request and the response.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	err := c.waitRateLimit(req)
+	if err != nil {
+		recordStatus(req, 0)
+
+		return nil, err
+	}
+
+	c.debugf(req.Context(), "request %s %s", req.Method, req.URL)
+
+	if c.requestHook != nil {
+		c.requestHook(req)
+	}
+
+	start := time.Now()
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to do req: %w", err)
+		recordStatus(req, 0)
+
+		c.debugf(req.Context(), "request %s %s failed: %v", req.Method, req.URL, err)
+
+		return nil, fmt.Errorf("failed to do req: %w", err)
+	}
+
+	c.debugf(req.Context(), "response %s %s: %d (%s)", req.Method, req.URL, resp.StatusCode, time.Since(start))
+
+	recordStatus(req, resp.StatusCode)
+
+	if c.responseHook != nil {
+		c.responseHook(resp)
 	}
 
+	return resp, nil
+}
+
+// readResponse reads and closes the response body, and unmarshals it into the result (unless nil).
+// A [ClientError] is returned for non-2xx responses.
+func (c *Client) readResponse(resp *http.Response, result any) error {
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode/100 != 2 {
-		raw, _ := io.ReadAll(resp.Body)
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, c.maxResponseBytes))
 
-		return newClientError("response error", resp.StatusCode, raw)
+		return newClientError("response error", resp.StatusCode, raw, resp.Header)
 	}
 
 	if result == nil {
 		return nil
 	}
 
-	raw, err := io.ReadAll(resp.Body)
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, c.maxResponseBytes+1))
 	if err != nil {
 		return fmt.Errorf("failed to read body: %w", err)
 	}
 
-	err = json.Unmarshal(raw, result)
+	if int64(len(raw)) > c.maxResponseBytes {
+		return fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, c.maxResponseBytes)
+	}
+
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return ErrEmptyBody
+	}
+
+	err = c.unmarshal(raw, result)
 	if err != nil {
-		return newClientError("failed to unmarshal response", resp.StatusCode, raw)
+		cErr := newClientError("failed to unmarshal response", resp.StatusCode, raw, resp.Header)
+
+		if c.strictDecoding {
+			return fmt.Errorf("%w: %w", cErr, err)
+		}
+
+		return cErr
 	}
 
 	return nil
 }
 
-func newRequest(ctx context.Context, endpoint *url.URL, headers map[string]string, payload any) (*http.Request, error) {
-	buf := new(bytes.Buffer)
+// cloneRequest returns a copy of the request to be sent again, with a fresh body and request ID.
+func (c *Client) cloneRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get request body: %w", err)
+		}
+
+		clone.Body = body
+	}
+
+	if c.requestID != nil {
+		clone.Header.Set("X-Request-Id", c.requestID())
+	}
+
+	return clone, nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method string, endpoint *url.URL, headers map[string]string, payload any) (*http.Request, error) {
+	var body io.Reader = http.NoBody
 
 	if payload != nil {
-		err := json.NewEncoder(buf).Encode(payload)
+		var err error
+
+		body, err = c.encodePayload(payload)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request JSON body: %w", err)
+			return nil, err
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.String(), buf)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), body)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create request: %w", err)
 	}
 
+	for h, values := range c.defaultHeaders {
+		req.Header[http.CanonicalHeaderKey(h)] = slices.Clone(values)
+	}
+
+	if c.headerFunc != nil {
+		extra, err := c.headerFunc(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get request headers: %w", err)
+		}
+
+		for h := range extra {
+			req.Header.Del(h)
+		}
+
+		for h, values := range extra {
+			for _, v := range values {
+				req.Header.Add(h, v)
+			}
+		}
+	}
+
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", userAgent())
 
+	if c.requestID != nil {
+		req.Header.Set("X-Request-Id", c.requestID())
+	}
+
+	if c.basicAuth != nil {
+		pass, _ := c.basicAuth.Password()
+		req.SetBasicAuth(c.basicAuth.Username(), pass)
+	}
+
 	for h, v := range headers {
 		req.Header.Set(h, v)
 	}
 
 	if payload != nil {
-		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Type", string(c.contentType))
 	}
 
 	return req, nil
@@ -1,13 +1,20 @@
 package goacmedns
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 const updateValue = "idkmybffjill"
@@ -22,8 +29,122 @@ var (
 	}
 )
 
+func TestNewClient(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		BaseURL         string
+		ExpectedBaseURL string
+		ExpectedErr     error
+	}{
+		{
+			Name:            "base URL",
+			BaseURL:         "https://auth.example.org",
+			ExpectedBaseURL: "https://auth.example.org",
+		},
+		{
+			Name:            "trailing slash",
+			BaseURL:         "https://auth.example.org/",
+			ExpectedBaseURL: "https://auth.example.org",
+		},
+		{
+			Name:            "subpath",
+			BaseURL:         "https://auth.example.org/acmedns",
+			ExpectedBaseURL: "https://auth.example.org/acmedns",
+		},
+		{
+			Name:            "subpath with trailing slash",
+			BaseURL:         "https://auth.example.org/acmedns//",
+			ExpectedBaseURL: "https://auth.example.org/acmedns",
+		},
+		{
+			Name:        "schemeless",
+			BaseURL:     "auth.example.org",
+			ExpectedErr: ErrInvalidBaseURL,
+		},
+		{
+			Name:        "schemeless with port",
+			BaseURL:     "auth.example.org:443",
+			ExpectedErr: ErrInvalidBaseURL,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, err := NewClient(tc.BaseURL)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			if tc.ExpectedErr != nil {
+				return
+			}
+
+			if client.baseURL.String() != tc.ExpectedBaseURL {
+				t.Errorf("expected base URL %q, got %q", tc.ExpectedBaseURL, client.baseURL)
+			}
+
+			expectedRegister := tc.ExpectedBaseURL + "/register"
+			if register := client.baseURL.JoinPath("register").String(); register != expectedRegister {
+				t.Errorf("expected register endpoint %q, got %q", expectedRegister, register)
+			}
+		})
+	}
+}
+
+func TestNewClientForAccount(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/update", updateTXTHandler(t))
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	account := testAcct
+	account.ServerURL = server.URL
+
+	client, err := NewClientForAccount(account, WithRetries(1, 0))
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	if client.baseURL.String() != server.URL {
+		t.Errorf("expected base URL %q, got %q", server.URL, client.baseURL)
+	}
+
+	if client.retries != 1 {
+		t.Errorf("expected the options to be applied, got %d retries", client.retries)
+	}
+
+	err = client.UpdateTXTRecord(context.Background(), account, updateValue)
+	if err != nil {
+		t.Errorf("unexpected error updating TXT record: %v", err)
+	}
+}
+
+func TestNewClientForAccount_errors(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		ServerURL   string
+		ExpectedErr error
+	}{
+		{Name: "empty server URL", ExpectedErr: ErrMissingServerURL},
+		{Name: "invalid server URL", ServerURL: "auth.example.org", ExpectedErr: ErrInvalidBaseURL},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			account := testAcct
+			account.ServerURL = tc.ServerURL
+
+			_, err := NewClientForAccount(account)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
+
 func TestClient_RegisterAccount(t *testing.T) {
-	testAllowFrom := []string{"space", "earth"}
+	testAllowFrom := []string{"192.168.100.1/24", "2002:c0a8:2a00::0/40"}
 
 	testCases := []struct {
 		Name            string
@@ -56,7 +177,9 @@ func TestClient_RegisterAccount(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			client, mux := setupTest(t)
+			clock := newFakeClock()
+
+			client, mux := setupTest(t, WithClock(clock))
 			mux.HandleFunc("/register", tc.RegisterHandler)
 
 			acct, err := client.RegisterAccount(context.Background(), tc.AllowFrom)
@@ -77,7 +200,12 @@ func TestClient_RegisterAccount(t *testing.T) {
 				var cErr *ClientError
 				if ok := errors.As(errors.Unwrap(err), &cErr); !ok {
 					t.Fatalf("expected ClientError from RegisterAccount. Got %T", errors.Unwrap(err))
-				} else if !reflect.DeepEqual(cErr, tc.ExpectedErr) {
+				}
+
+				// The response headers are dynamic (e.g. Date), they are asserted by TestClientError_Header.
+				cErr.Header = nil
+
+				if !reflect.DeepEqual(cErr, tc.ExpectedErr) {
 					t.Errorf("got %#v,\n expected err %#v", errors.Unwrap(err), tc.ExpectedErr)
 				}
 
@@ -85,12 +213,351 @@ func TestClient_RegisterAccount(t *testing.T) {
 			}
 
 			if tc.ExpectedErr == nil && err == nil {
+				expected := *tc.ExpectedAccount
 				// Needed to be able to assert equivalence, as the server addr is dynamic
-				tc.ExpectedAccount.ServerURL = acct.ServerURL
+				expected.ServerURL = acct.ServerURL
+				expected.CreatedAt = clock.Now()
+
+				if !reflect.DeepEqual(acct, expected) {
+					t.Errorf("expected account %v, got %v\n", expected, acct)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_RegisterAccount_emptyBody(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Status int
+	}{
+		{Name: "200 OK", Status: http.StatusOK},
+		{Name: "201 Created", Status: http.StatusCreated},
+		{Name: "204 No Content", Status: http.StatusNoContent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t)
+
+			mux.HandleFunc("/register", func(resp http.ResponseWriter, _ *http.Request) {
+				resp.WriteHeader(tc.Status)
+			})
+
+			_, err := client.RegisterAccount(context.Background(), nil)
+			if !errors.Is(err, ErrEmptyBody) {
+				t.Errorf("expected ErrEmptyBody, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_RegisterAccount_serverURL(t *testing.T) {
+	testCases := []struct {
+		Name              string
+		Options           []Option
+		ExpectedServerURL func(baseURL string) string
+	}{
+		{
+			Name:              "default",
+			ExpectedServerURL: func(baseURL string) string { return baseURL },
+		},
+		{
+			Name:              "with server URL",
+			Options:           []Option{WithServerURL("https://acme-dns.example.com")},
+			ExpectedServerURL: func(string) string { return "https://acme-dns.example.com" },
+		},
+		{
+			Name:              "without server URL stamp",
+			Options:           []Option{WithoutServerURLStamp()},
+			ExpectedServerURL: func(string) string { return "" },
+		},
+		{
+			Name:              "last option wins",
+			Options:           []Option{WithoutServerURLStamp(), WithServerURL("https://acme-dns.example.com")},
+			ExpectedServerURL: func(string) string { return "https://acme-dns.example.com" },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t, tc.Options...)
+			mux.HandleFunc("/register", newRegHandler(t, nil))
+
+			acct, err := client.RegisterAccount(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if expected := tc.ExpectedServerURL(client.baseURL.String()); acct.ServerURL != expected {
+				t.Errorf("expected server URL %q, got %q", expected, acct.ServerURL)
+			}
+		})
+	}
+}
+
+func TestWithResponseValidator(t *testing.T) {
+	errCustom := errors.New("unexpected subdomain")
+
+	testCases := []struct {
+		Name        string
+		Account     Account
+		Option      Option
+		ExpectedErr error
+	}{
+		{
+			Name:    "no validator",
+			Account: Account{Username: "cpu", Password: "hunter2"},
+		},
+		{
+			Name:        "default validator, missing subdomain",
+			Account:     Account{FullDomain: testAcct.FullDomain, Username: "cpu", Password: "hunter2"},
+			Option:      WithDefaultResponseValidator(),
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name:        "default validator, missing full domain",
+			Account:     Account{SubDomain: testAcct.SubDomain, Username: "cpu", Password: "hunter2"},
+			Option:      WithDefaultResponseValidator(),
+			ExpectedErr: ErrInvalidAccount,
+		},
+		{
+			Name:    "default validator, complete account",
+			Account: testAcct,
+			Option:  WithDefaultResponseValidator(),
+		},
+		{
+			Name:        "custom validator",
+			Account:     testAcct,
+			Option:      WithResponseValidator(func(Account) error { return errCustom }),
+			ExpectedErr: errCustom,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var opts []Option
+			if tc.Option != nil {
+				opts = append(opts, tc.Option)
+			}
+
+			client, mux := setupTest(t, opts...)
+			mux.HandleFunc("/register", func(resp http.ResponseWriter, _ *http.Request) {
+				resp.WriteHeader(http.StatusCreated)
+				_ = json.NewEncoder(resp).Encode(tc.Account)
+			})
+
+			acct, err := client.RegisterAccount(context.Background(), nil)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			if tc.ExpectedErr != nil && !reflect.DeepEqual(acct, Account{}) {
+				t.Errorf("expected no account, got %v", acct)
+			}
+		})
+	}
+}
+
+func TestValidateAllowFrom(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		AllowFrom   []string
+		ExpectedErr error
+	}{
+		{Name: "empty"},
+		{Name: "IPv4", AllowFrom: []string{"192.168.100.1/24", "1.2.3.4/32"}},
+		{Name: "IPv6", AllowFrom: []string{"2002:c0a8:2a00::0/40", "::1/128"}},
+		{Name: "invalid prefix length", AllowFrom: []string{"10.0.0.0/8", "10.0.0.0/33"}, ExpectedErr: ErrInvalidAllowFrom},
+		{Name: "missing prefix length", AllowFrom: []string{"10.0.0.1"}, ExpectedErr: ErrInvalidAllowFrom},
+		{Name: "not an IP", AllowFrom: []string{"example.com/24"}, ExpectedErr: ErrInvalidAllowFrom},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := ValidateAllowFrom(tc.AllowFrom)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
+
+func TestClient_RegisterAccount_invalidAllowFrom(t *testing.T) {
+	client, mux := setupTest(t)
+
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, _ *http.Request) {
+		t.Error("expected no registration request")
+
+		resp.WriteHeader(http.StatusCreated)
+	})
+
+	_, err := client.RegisterAccount(context.Background(), []string{"10.0.0.0/8", "10.0.0.0/33"})
+	if !errors.Is(err, ErrInvalidAllowFrom) {
+		t.Fatalf("expected ErrInvalidAllowFrom, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), `"10.0.0.0/33"`) {
+		t.Errorf("expected the error to identify the invalid entry, got %v", err)
+	}
+}
+
+func TestClient_RegisterAccount_allowFromEchoed(t *testing.T) {
+	testAllowFrom := []string{"192.168.100.1/24", "2002:c0a8:2a00::0/40"}
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, req *http.Request) {
+		var regReq Register
+
+		err := json.NewDecoder(req.Body).Decode(&regReq)
+		if err != nil {
+			t.Fatalf("error decoding request body JSON: %v", err)
+		}
+
+		acct := testAcct
+		acct.AllowFrom = regReq.AllowFrom
+
+		resp.WriteHeader(http.StatusCreated)
 
-				if !reflect.DeepEqual(acct, *tc.ExpectedAccount) {
-					t.Errorf("expected account %v, got %v\n", tc.ExpectedAccount, acct)
+		newRegBody, _ := json.Marshal(acct)
+		_, _ = resp.Write(newRegBody)
+	})
+
+	acct, err := client.RegisterAccount(context.Background(), testAllowFrom)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(acct.AllowFrom, testAllowFrom) {
+		t.Errorf("expected AllowFrom %#v, got %#v", testAllowFrom, acct.AllowFrom)
+	}
+}
+
+func TestClient_RegisterAccountWithSubdomain(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		AllowFrom []string
+		SubDomain string
+	}{
+		{
+			Name:      "with subdomain",
+			SubDomain: "deterministic",
+		},
+		{
+			Name:      "with subdomain, allow from",
+			AllowFrom: []string{"192.168.100.1/24", "2002:c0a8:2a00::0/40"},
+			SubDomain: "deterministic",
+		},
+		{
+			Name:      "without subdomain, allow from",
+			AllowFrom: []string{"192.168.100.1/24", "2002:c0a8:2a00::0/40"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t)
+			mux.HandleFunc("/register", func(resp http.ResponseWriter, req *http.Request) {
+				var payload map[string]any
+
+				err := json.NewDecoder(req.Body).Decode(&payload)
+				if err != nil {
+					t.Fatalf("error decoding request body JSON: %v", err)
+				}
+
+				subdomain, found := payload["subdomain"]
+
+				switch {
+				case tc.SubDomain == "" && found:
+					t.Errorf("expected no subdomain field, got %v", subdomain)
+				case tc.SubDomain != "" && subdomain != tc.SubDomain:
+					t.Errorf("expected subdomain %q, got %v", tc.SubDomain, subdomain)
 				}
+
+				resp.WriteHeader(http.StatusCreated)
+
+				newRegBody, _ := json.Marshal(testAcct)
+				_, _ = resp.Write(newRegBody)
+			})
+
+			_, err := client.RegisterAccountWithSubdomain(context.Background(), tc.AllowFrom, tc.SubDomain)
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_RegisterAccountRaw(t *testing.T) {
+	type forkRegister struct {
+		AllowFrom []string          `json:"allowfrom"`
+		TTL       int               `json:"ttl"`
+		Labels    map[string]string `json:"labels"`
+	}
+
+	payload := forkRegister{
+		AllowFrom: []string{"192.168.100.1/24"},
+		TTL:       60,
+		Labels:    map[string]string{"team": "infra"},
+	}
+
+	clock := newFakeClock()
+
+	client, mux := setupTest(t, WithRequireAllowFrom(), WithClock(clock))
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, req *http.Request) {
+		var received forkRegister
+
+		err := json.NewDecoder(req.Body).Decode(&received)
+		if err != nil {
+			t.Errorf("error decoding request body JSON: %v", err)
+		}
+
+		if !reflect.DeepEqual(received, payload) {
+			t.Errorf("expected payload %#v, got %#v", payload, received)
+		}
+
+		resp.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(resp).Encode(testAcct)
+	})
+
+	acct, err := client.RegisterAccountRaw(context.Background(), payload)
+	if err != nil {
+		t.Fatalf("unexpected error registering account: %v", err)
+	}
+
+	expected := testAcct
+	expected.ServerURL = client.baseURL.String()
+	expected.CreatedAt = clock.Now()
+
+	if !reflect.DeepEqual(acct, expected) {
+		t.Errorf("expected account %v, got %v", expected, acct)
+	}
+}
+
+func TestClient_RegisterAccount_requireAllowFrom(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		AllowFrom   []string
+		ExpectedErr error
+	}{
+		{
+			Name:        "empty allow from",
+			ExpectedErr: ErrAllowFromRequired,
+		},
+		{
+			Name:      "non-empty allow from",
+			AllowFrom: []string{"192.168.100.1/24", "2002:c0a8:2a00::0/40"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t, WithRequireAllowFrom())
+			mux.HandleFunc("/register", newRegHandler(t, tc.AllowFrom))
+
+			_, err := client.RegisterAccount(context.Background(), tc.AllowFrom)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
 			}
 		})
 	}
@@ -137,7 +604,12 @@ func TestClient_UpdateTXTRecord(t *testing.T) {
 				var cErr *ClientError
 				if ok := errors.As(errors.Unwrap(err), &cErr); !ok {
 					t.Fatalf("expected ClientError from UpdateTXTRecord. Got %v", errors.Unwrap(err))
-				} else if !reflect.DeepEqual(cErr, tc.ExpectedErr) {
+				}
+
+				// The response headers are dynamic (e.g. Date), they are asserted by TestClientError_Header.
+				cErr.Header = nil
+
+				if !reflect.DeepEqual(cErr, tc.ExpectedErr) {
 					t.Errorf("expected err %#v, got %#v\n", tc.ExpectedErr, cErr)
 				}
 			}
@@ -145,6 +617,1114 @@ func TestClient_UpdateTXTRecord(t *testing.T) {
 	}
 }
 
+func TestClient_UpdateTXTRecordResponse(t *testing.T) {
+	client, mux := setupTest(t)
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		var update Update
+
+		err := json.NewDecoder(req.Body).Decode(&update)
+		if err != nil {
+			t.Errorf("error decoding request body JSON: %v", err)
+		}
+
+		_ = json.NewEncoder(resp).Encode(UpdateResponse{Txt: update.Txt})
+	})
+
+	resp, err := client.UpdateTXTRecordResponse(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT record: %v", err)
+	}
+
+	expected := UpdateResponse{Txt: updateValue}
+	if resp != expected {
+		t.Errorf("expected response %#v, got %#v", expected, resp)
+	}
+}
+
+func TestClient_UpdateTXTRecordResponse_errors(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		UpdateHandler  http.HandlerFunc
+		ExpectedStatus int
+		ExpectedErr    error
+	}{
+		{
+			Name:           "update failure",
+			UpdateHandler:  errHandler,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name: "empty body",
+			UpdateHandler: func(resp http.ResponseWriter, _ *http.Request) {
+				resp.WriteHeader(http.StatusOK)
+			},
+			ExpectedErr: ErrEmptyBody,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t)
+			mux.HandleFunc("/update", tc.UpdateHandler)
+
+			_, err := client.UpdateTXTRecordResponse(context.Background(), testAcct, updateValue)
+
+			if tc.ExpectedErr != nil {
+				if !errors.Is(err, tc.ExpectedErr) {
+					t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+				}
+
+				return
+			}
+
+			assertStatus(t, err, tc.ExpectedStatus)
+		})
+	}
+}
+
+func TestClient_ClearTXTRecord(t *testing.T) {
+	client, mux := setupTest(t)
+
+	// Like ACME-DNS, keeps the two most recent values.
+	values := []string{"challenge1", "challenge2"}
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		var update Update
+
+		err := json.NewDecoder(req.Body).Decode(&update)
+		if err != nil || len(update.Txt) != 43 {
+			resp.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		values = []string{values[1], update.Txt}
+
+		_ = json.NewEncoder(resp).Encode(UpdateResponse{Txt: update.Txt})
+	})
+
+	err := client.ClearTXTRecord(context.Background(), testAcct)
+	if err != nil {
+		t.Fatalf("unexpected error clearing TXT record: %v", err)
+	}
+
+	expected := []string{ClearedTXTValue, ClearedTXTValue}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected TXT values %q, got %q", expected, values)
+	}
+}
+
+func TestClient_ClearTXTRecord_error(t *testing.T) {
+	client, mux := setupTest(t)
+
+	var calls int
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		calls++
+
+		errHandler(resp, req)
+	})
+
+	err := client.ClearTXTRecord(context.Background(), testAcct)
+	assertStatus(t, err, http.StatusBadRequest)
+
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestClient_UpdateTXTRecordWithCredentials(t *testing.T) {
+	client, mux := setupTest(t)
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		if user := req.Header.Get("X-Api-User"); user != "rotated-user" {
+			t.Errorf("expected X-Api-User %q got %q", "rotated-user", user)
+		}
+
+		if key := req.Header.Get("X-Api-Key"); key != "rotated-key" {
+			t.Errorf("expected X-Api-Key %q got %q", "rotated-key", key)
+		}
+
+		var update Update
+
+		err := json.NewDecoder(req.Body).Decode(&update)
+		if err != nil {
+			t.Fatalf("error decoding request body JSON: %v", err)
+		}
+
+		if update.SubDomain != testAcct.SubDomain {
+			t.Errorf("expected subdomain %q got %q", testAcct.SubDomain, update.SubDomain)
+		}
+
+		resp.WriteHeader(http.StatusOK)
+	})
+
+	acct := testAcct
+
+	err := client.UpdateTXTRecordWithCredentials(context.Background(), acct, "rotated-user", "rotated-key", updateValue)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(acct, testAcct) {
+		t.Errorf("expected account to be unchanged, got %v", acct)
+	}
+}
+
+func TestClient_RotatePassword(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Handler        http.HandlerFunc
+		ExpectedStatus int
+		ExpectedErr    error
+	}{
+		{
+			Name: "rotated",
+			Handler: func(resp http.ResponseWriter, _ *http.Request) {
+				_, _ = resp.Write([]byte(`{"password":"new-password"}`))
+			},
+		},
+		{
+			Name:           "unsupported",
+			Handler:        http.NotFound,
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedErr:    ErrUnsupportedByServer,
+		},
+		{
+			Name: "method not allowed",
+			Handler: func(resp http.ResponseWriter, _ *http.Request) {
+				resp.WriteHeader(http.StatusMethodNotAllowed)
+			},
+			ExpectedStatus: http.StatusMethodNotAllowed,
+			ExpectedErr:    ErrUnsupportedByServer,
+		},
+		{
+			Name: "missing password",
+			Handler: func(resp http.ResponseWriter, _ *http.Request) {
+				_, _ = resp.Write([]byte(`{}`))
+			},
+			ExpectedErr: ErrMissingPassword,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t)
+
+			mux.HandleFunc("/rotate", func(resp http.ResponseWriter, req *http.Request) {
+				if req.Method != http.MethodPost {
+					t.Errorf("expected method %q got %q", http.MethodPost, req.Method)
+				}
+
+				if user := req.Header.Get("X-Api-User"); user != testAcct.Username {
+					t.Errorf("expected X-Api-User %q got %q", testAcct.Username, user)
+				}
+
+				if key := req.Header.Get("X-Api-Key"); key != testAcct.Password {
+					t.Errorf("expected X-Api-Key %q got %q", testAcct.Password, key)
+				}
+
+				tc.Handler(resp, req)
+			})
+
+			acct, err := client.RotatePassword(context.Background(), testAcct)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			if tc.ExpectedStatus != 0 {
+				assertStatus(t, err, tc.ExpectedStatus)
+			}
+
+			if err != nil {
+				return
+			}
+
+			expected := testAcct
+			expected.Password = "new-password"
+
+			if !reflect.DeepEqual(acct, expected) {
+				t.Errorf("expected account %v, got %v", expected, acct)
+			}
+		})
+	}
+}
+
+func TestClientError_Header(t *testing.T) {
+	client, mux := setupTest(t)
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, _ *http.Request) {
+		resp.Header().Set("Retry-After", "120")
+		resp.WriteHeader(http.StatusTooManyRequests)
+		_, _ = resp.Write(errBody)
+	})
+
+	err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+
+	var cErr *ClientError
+	if !errors.As(err, &cErr) {
+		t.Fatalf("expected ClientError from UpdateTXTRecord. Got %v", err)
+	}
+
+	if retryAfter := cErr.Header.Get("Retry-After"); retryAfter != "120" {
+		t.Errorf("expected Retry-After header %q, got %q", "120", retryAfter)
+	}
+
+	expectedMsg := `429: response error, response: {"error":"this is a test"}`
+	if cErr.Error() != expectedMsg {
+		t.Errorf("expected error message %q, got %q", expectedMsg, cErr.Error())
+	}
+}
+
+func TestClientError_problemDetails(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		ContentType    string
+		Body           string
+		ExpectedTitle  string
+		ExpectedDetail string
+		ExpectedMsg    string
+	}{
+		{
+			Name:           "problem details",
+			ContentType:    "application/problem+json; charset=utf-8",
+			Body:           `{"type":"about:blank","title":"Forbidden","detail":"IP not allowed by the gateway"}`,
+			ExpectedTitle:  "Forbidden",
+			ExpectedDetail: "IP not allowed by the gateway",
+			ExpectedMsg:    "403: response error, problem: Forbidden: IP not allowed by the gateway",
+		},
+		{
+			Name:          "problem details without detail",
+			ContentType:   "application/problem+json",
+			Body:          `{"title":"Forbidden"}`,
+			ExpectedTitle: "Forbidden",
+			ExpectedMsg:   "403: response error, problem: Forbidden",
+		},
+		{
+			Name:        "invalid problem details",
+			ContentType: "application/problem+json",
+			Body:        `Forbidden`,
+			ExpectedMsg: "403: response error, response: Forbidden",
+		},
+		{
+			Name:        "JSON",
+			ContentType: "application/json",
+			Body:        `{"title":"Forbidden"}`,
+			ExpectedMsg: `403: response error, response: {"title":"Forbidden"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t)
+			mux.HandleFunc("/update", func(resp http.ResponseWriter, _ *http.Request) {
+				resp.Header().Set("Content-Type", tc.ContentType)
+				resp.WriteHeader(http.StatusForbidden)
+				_, _ = resp.Write([]byte(tc.Body))
+			})
+
+			err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+
+			var cErr *ClientError
+			if !errors.As(err, &cErr) {
+				t.Fatalf("expected ClientError from UpdateTXTRecord. Got %v", err)
+			}
+
+			if cErr.Title != tc.ExpectedTitle || cErr.Detail != tc.ExpectedDetail {
+				t.Errorf("expected title %q and detail %q, got %q and %q", tc.ExpectedTitle, tc.ExpectedDetail, cErr.Title, cErr.Detail)
+			}
+
+			if cErr.Error() != tc.ExpectedMsg {
+				t.Errorf("expected error message %q, got %q", tc.ExpectedMsg, cErr.Error())
+			}
+		})
+	}
+}
+
+func TestWithRequestIDFunc(t *testing.T) {
+	var counter int
+
+	client, mux := setupTest(t, WithRequestIDFunc(func() string {
+		counter++
+
+		return "req-" + strconv.Itoa(counter)
+	}))
+
+	var ids []string
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		ids = append(ids, req.Header.Get("X-Request-Id"))
+
+		resp.WriteHeader(http.StatusOK)
+	})
+
+	for range 2 {
+		err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	expected := []string{"req-1", "req-2"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected request IDs %v, got %v", expected, ids)
+	}
+}
+
+func TestWithRequestIDFunc_unset(t *testing.T) {
+	client, mux := setupTest(t)
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		if _, found := req.Header["X-Request-Id"]; found {
+			t.Errorf("expected no X-Request-Id header, got %q", req.Header.Get("X-Request-Id"))
+		}
+
+		resp.WriteHeader(http.StatusOK)
+	})
+
+	err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestWithRequestHook_WithResponseHook(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Handler        http.HandlerFunc
+		ExpectedStatus int
+	}{
+		{
+			Name:           "success",
+			Handler:        updateTXTHandler(t),
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "error response",
+			Handler:        errHandler,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var (
+				hookedReq  *http.Request
+				hookedResp *http.Response
+			)
+
+			client, mux := setupTest(t,
+				WithRequestHook(func(req *http.Request) { hookedReq = req }),
+				WithResponseHook(func(resp *http.Response) { hookedResp = resp }),
+			)
+			mux.HandleFunc("/update", tc.Handler)
+
+			_ = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+
+			endpoint := client.baseURL.JoinPath("update").String()
+
+			if hookedReq == nil || hookedReq.URL.String() != endpoint {
+				t.Fatalf("expected request hook to be called with a request to %q, got %v", endpoint, hookedReq)
+			}
+
+			if hookedResp == nil {
+				t.Fatal("expected response hook to be called")
+			}
+
+			if hookedResp.Request.URL.String() != endpoint {
+				t.Errorf("expected the hooked response to be for %q, got %q", endpoint, hookedResp.Request.URL)
+			}
+
+			if hookedResp.StatusCode != tc.ExpectedStatus {
+				t.Errorf("expected response status %d, got %d", tc.ExpectedStatus, hookedResp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestWithMaxResponseBytes(t *testing.T) {
+	client, mux := setupTest(t, WithMaxResponseBytes(64))
+
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, _ *http.Request) {
+		resp.WriteHeader(http.StatusCreated)
+		_, _ = resp.Write(bytes.Repeat([]byte(" "), 128))
+	})
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, _ *http.Request) {
+		resp.WriteHeader(http.StatusBadRequest)
+		_, _ = resp.Write(bytes.Repeat([]byte("e"), 128))
+	})
+
+	_, err := client.RegisterAccount(context.Background(), nil)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+
+	err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+
+	var cErr *ClientError
+	if !errors.As(err, &cErr) {
+		t.Fatalf("expected ClientError from UpdateTXTRecord. Got %v", err)
+	}
+
+	if len(cErr.Body) != 64 {
+		t.Errorf("expected error body to be truncated to 64 bytes, got %d", len(cErr.Body))
+	}
+}
+
+func TestWithCallTimeout(t *testing.T) {
+	client, mux := setupTest(t, WithCallTimeout(50*time.Millisecond))
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+
+		resp.WriteHeader(http.StatusOK)
+	})
+
+	start := time.Now()
+
+	err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the call to time out after 50ms, took %s", elapsed)
+	}
+}
+
+func TestWithBasicAuth(t *testing.T) {
+	basicAuth := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(resp http.ResponseWriter, req *http.Request) {
+			user, pass, ok := req.BasicAuth()
+			if !ok || user != "proxy-user" || pass != "proxy-pass" {
+				resp.WriteHeader(http.StatusUnauthorized)
+
+				return
+			}
+
+			next(resp, req)
+		}
+	}
+
+	testCases := []struct {
+		Name           string
+		Options        []Option
+		ExpectedStatus int
+	}{
+		{
+			Name:    "with basic auth",
+			Options: []Option{WithBasicAuth("proxy-user", "proxy-pass")},
+		},
+		{
+			Name:           "without basic auth",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "wrong credentials",
+			Options:        []Option{WithBasicAuth("proxy-user", "wrong")},
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t, tc.Options...)
+			mux.HandleFunc("/register", basicAuth(newRegHandler(t, nil)))
+			mux.HandleFunc("/update", basicAuth(updateTXTHandler(t)))
+
+			_, err := client.RegisterAccount(context.Background(), nil)
+			assertStatus(t, err, tc.ExpectedStatus)
+
+			err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+			assertStatus(t, err, tc.ExpectedStatus)
+		})
+	}
+}
+
+func assertStatus(t *testing.T, err error, expected int) {
+	t.Helper()
+
+	if expected == 0 {
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+
+		return
+	}
+
+	var cErr *ClientError
+	if !errors.As(err, &cErr) {
+		t.Fatalf("expected ClientError, got %v", err)
+	}
+
+	if cErr.HTTPStatus != expected {
+		t.Errorf("expected HTTP status %d, got %d", expected, cErr.HTTPStatus)
+	}
+}
+
+func TestWithHeaderFunc(t *testing.T) {
+	var counter int
+
+	client, mux := setupTest(t, WithHeaderFunc(func(_ context.Context) (http.Header, error) {
+		counter++
+
+		return http.Header{
+			"X-Proxy-Token": []string{"token-" + strconv.Itoa(counter)},
+			"User-Agent":    []string{"overridden"},
+		}, nil
+	}))
+
+	var tokens []string
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		tokens = append(tokens, req.Header.Get("X-Proxy-Token"))
+
+		updateTXTHandler(t)(resp, req)
+	})
+
+	for range 2 {
+		err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	expected := []string{"token-1", "token-2"}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expected proxy tokens %v, got %v", expected, tokens)
+	}
+}
+
+func TestWithHeaderFunc_error(t *testing.T) {
+	errToken := errors.New("token unavailable")
+
+	client, mux := setupTest(t, WithHeaderFunc(func(_ context.Context) (http.Header, error) {
+		return nil, errToken
+	}))
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, _ *http.Request) {
+		t.Error("expected no request to be sent")
+
+		resp.WriteHeader(http.StatusOK)
+	})
+
+	err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if !errors.Is(err, errToken) {
+		t.Errorf("expected error %v, got %v", errToken, err)
+	}
+}
+
+func TestWithDefaultHeaders(t *testing.T) {
+	headers := http.Header{
+		"X-Tenant":   []string{"acme"},
+		"X-Proxy":    []string{"default"},
+		"X-Api-User": []string{"overridden"},
+		"User-Agent": []string{"overridden"},
+	}
+
+	client, mux := setupTest(t,
+		WithDefaultHeaders(headers),
+		WithHeaderFunc(func(_ context.Context) (http.Header, error) {
+			return http.Header{"X-Proxy": []string{"dynamic"}}, nil
+		}),
+	)
+
+	// The headers are copied by the option.
+	headers.Set("X-Tenant", "changed")
+
+	var received http.Header
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		received = req.Header
+
+		// Checks the credentials and the User-Agent.
+		updateTXTHandler(t)(resp, req)
+	})
+
+	err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if tenant := received.Get("X-Tenant"); tenant != "acme" {
+		t.Errorf("expected X-Tenant %q, got %q", "acme", tenant)
+	}
+
+	if proxy := received.Values("X-Proxy"); !reflect.DeepEqual(proxy, []string{"dynamic"}) {
+		t.Errorf("expected X-Proxy %q, got %q", []string{"dynamic"}, proxy)
+	}
+}
+
+func TestWithoutEnvProxy(t *testing.T) {
+	var proxied bool
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, _ *http.Request) {
+		proxied = true
+
+		resp.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(proxy.Close)
+
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("HTTPS_PROXY", proxy.URL)
+
+	client, mux := setupTest(t, WithoutEnvProxy())
+	mux.HandleFunc("/update", updateTXTHandler(t))
+
+	if client.transport.Proxy != nil {
+		t.Error("expected the transport proxy to be disabled")
+	}
+
+	err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if proxied {
+		t.Error("expected the request to not go through the proxy")
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var proxiedURL string
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		proxiedURL = req.URL.String()
+
+		resp.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(proxy.Close)
+
+	client, err := NewClient("http://acme-dns.invalid", WithProxy(proxy.URL))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	expected := "http://acme-dns.invalid/update"
+	if proxiedURL != expected {
+		t.Errorf("expected the proxy to receive a request for %q, got %q", expected, proxiedURL)
+	}
+}
+
+func TestWithProxy_invalid(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		ProxyURL string
+	}{
+		{Name: "unparsable", ProxyURL: "http://[::1"},
+		{Name: "relative", ProxyURL: "proxy.example.com:3128"},
+		{Name: "empty", ProxyURL: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := NewClient("https://auth.acme-dns.io", WithProxy(tc.ProxyURL))
+			if !errors.Is(err, ErrInvalidProxyURL) {
+				t.Errorf("expected ErrInvalidProxyURL, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWithTransportConfig(t *testing.T) {
+	client, err := NewClient("https://auth.acme-dns.io", WithTransportConfig(100, 20, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.transport.MaxIdleConns != 100 {
+		t.Errorf("expected MaxIdleConns %d, got %d", 100, client.transport.MaxIdleConns)
+	}
+
+	if client.transport.MaxIdleConnsPerHost != 20 {
+		t.Errorf("expected MaxIdleConnsPerHost %d, got %d", 20, client.transport.MaxIdleConnsPerHost)
+	}
+
+	if client.transport.IdleConnTimeout != time.Minute {
+		t.Errorf("expected IdleConnTimeout %s, got %s", time.Minute, client.transport.IdleConnTimeout)
+	}
+
+	// The custom HTTP client takes precedence over the transport configuration.
+	custom := &http.Client{}
+
+	client, err = NewClient("https://auth.acme-dns.io", WithTransportConfig(100, 20, time.Minute), WithHTTPClient(custom))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.httpClient != custom || custom.Transport != nil {
+		t.Error("expected the custom HTTP client to be used as is")
+	}
+}
+
+func TestWithFallbackURL(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, _ *http.Request) {
+		resp.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+
+	rejecting := httptest.NewServer(http.HandlerFunc(errHandler))
+	t.Cleanup(rejecting.Close)
+
+	testCases := []struct {
+		Name              string
+		PrimaryURL        string
+		ExpectedFallback  bool
+		ExpectedErrStatus int
+	}{
+		{
+			Name:             "primary down",
+			PrimaryURL:       down.URL,
+			ExpectedFallback: true,
+		},
+		{
+			Name:             "primary 5xx",
+			PrimaryURL:       failing.URL,
+			ExpectedFallback: true,
+		},
+		{
+			Name:              "primary 4xx",
+			PrimaryURL:        rejecting.URL,
+			ExpectedErrStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var fallbackCalls int
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/register", func(resp http.ResponseWriter, req *http.Request) {
+				fallbackCalls++
+
+				newRegHandler(t, []string{"192.168.100.1/24"})(resp, req)
+			})
+
+			secondary := httptest.NewServer(mux)
+			t.Cleanup(secondary.Close)
+
+			client, err := NewClient(tc.PrimaryURL, WithFallbackURL(secondary.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			acct, err := client.RegisterAccount(context.Background(), []string{"192.168.100.1/24"})
+			assertStatus(t, err, tc.ExpectedErrStatus)
+
+			if !tc.ExpectedFallback {
+				if fallbackCalls != 0 {
+					t.Errorf("expected no request to the fallback server, got %d", fallbackCalls)
+				}
+
+				return
+			}
+
+			if fallbackCalls != 1 {
+				t.Errorf("expected 1 request to the fallback server, got %d", fallbackCalls)
+			}
+
+			if acct.ServerURL != secondary.URL {
+				t.Errorf("expected the account server URL %q, got %q", secondary.URL, acct.ServerURL)
+			}
+		})
+	}
+}
+
+func TestWithFallbackURL_invalid(t *testing.T) {
+	_, err := NewClient("https://auth.acme-dns.io", WithFallbackURL("auth2.acme-dns.io"))
+	if !errors.Is(err, ErrInvalidBaseURL) {
+		t.Errorf("expected ErrInvalidBaseURL, got %v", err)
+	}
+}
+
+func TestWithRequireHTTPS(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		BaseURL     string
+		Options     []Option
+		ExpectedErr error
+	}{
+		{
+			Name:    "https",
+			BaseURL: "https://auth.acme-dns.io",
+		},
+		{
+			Name:        "http",
+			BaseURL:     "http://auth.acme-dns.io",
+			ExpectedErr: ErrInsecureBaseURL,
+		},
+		{
+			Name:    "localhost",
+			BaseURL: "http://localhost:8080",
+		},
+		{
+			Name:    "IPv4 loopback",
+			BaseURL: "http://127.0.0.1:8080",
+		},
+		{
+			Name:    "IPv6 loopback",
+			BaseURL: "http://[::1]:8080",
+		},
+		{
+			Name:        "http fallback",
+			BaseURL:     "https://auth.acme-dns.io",
+			Options:     []Option{WithFallbackURL("http://auth2.acme-dns.io")},
+			ExpectedErr: ErrInsecureBaseURL,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := NewClient(tc.BaseURL, append(tc.Options, WithRequireHTTPS())...)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
+
+func TestNewClient_http(t *testing.T) {
+	_, err := NewClient("http://auth.acme-dns.io")
+	if err != nil {
+		t.Errorf("expected HTTP base URLs to be allowed by default, got %v", err)
+	}
+}
+
+func TestClient_Close(t *testing.T) {
+	closed := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/update", updateTXTHandler(t))
+
+	ts := httptest.NewUnstartedServer(mux)
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			close(closed)
+		}
+	}
+
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	client, err := NewClient(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	client.Close()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Error("expected the idle connection to be closed")
+	}
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/update", updateTXTHandler(t))
+
+	ts := httptest.NewTLSServer(mux)
+	t.Cleanup(ts.Close)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+
+	client, err := NewClient(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err == nil {
+		t.Error("expected an error without the server certificate pool, got nil")
+	}
+
+	client, err = NewClient(ts.URL, WithTLSConfig(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	// The custom HTTP client takes precedence over the TLS configuration.
+	client, err = NewClient(ts.URL,
+		WithTLSConfig(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}),
+		WithHTTPClient(&http.Client{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err == nil {
+		t.Error("expected an error with a custom HTTP client, got nil")
+	}
+}
+
+func TestClient_ValidateAccount(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		UpdateHandler http.HandlerFunc
+		ExpectedErr   error
+		ExpectedCode  int
+	}{
+		{
+			Name: "valid credentials",
+			UpdateHandler: func(resp http.ResponseWriter, req *http.Request) {
+				if key := req.Header.Get("X-Api-Key"); key != testAcct.Password {
+					t.Errorf("expected X-Api-Key %q got %q", testAcct.Password, key)
+				}
+
+				resp.WriteHeader(http.StatusBadRequest)
+				_, _ = resp.Write([]byte(`{"error":"bad_txt"}`))
+			},
+		},
+		{
+			Name: "unauthorized",
+			UpdateHandler: func(resp http.ResponseWriter, _ *http.Request) {
+				resp.WriteHeader(http.StatusUnauthorized)
+				_, _ = resp.Write([]byte(`{"error":"forbidden"}`))
+			},
+			ExpectedErr:  ErrUnauthorized,
+			ExpectedCode: http.StatusUnauthorized,
+		},
+		{
+			Name: "server error",
+			UpdateHandler: func(resp http.ResponseWriter, _ *http.Request) {
+				resp.WriteHeader(http.StatusInternalServerError)
+			},
+			ExpectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t)
+			mux.HandleFunc("/update", tc.UpdateHandler)
+
+			err := client.ValidateAccount(context.Background(), testAcct)
+
+			if tc.ExpectedCode == 0 {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			if errors.Is(err, ErrUnauthorized) != (tc.ExpectedErr != nil) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			var cErr *ClientError
+			if !errors.As(err, &cErr) {
+				t.Fatalf("expected ClientError from ValidateAccount. Got %v", err)
+			}
+
+			if cErr.HTTPStatus != tc.ExpectedCode {
+				t.Errorf("expected status %d, got %d", tc.ExpectedCode, cErr.HTTPStatus)
+			}
+		})
+	}
+}
+
+func TestClient_UpdateTXTRecordDryRun(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Account       Account
+		HealthHandler http.HandlerFunc
+		ExpectedErr   error
+	}{
+		{
+			Name:          "valid account, healthy server",
+			Account:       testAcct,
+			HealthHandler: healthHandler,
+		},
+		{
+			Name:          "missing username",
+			Account:       Account{SubDomain: testAcct.SubDomain, Password: testAcct.Password},
+			HealthHandler: healthHandler,
+			ExpectedErr:   ErrInvalidAccount,
+		},
+		{
+			Name:          "missing password",
+			Account:       Account{SubDomain: testAcct.SubDomain, Username: testAcct.Username},
+			HealthHandler: healthHandler,
+			ExpectedErr:   ErrInvalidAccount,
+		},
+		{
+			Name:          "missing subdomain",
+			Account:       Account{Username: testAcct.Username, Password: testAcct.Password},
+			HealthHandler: healthHandler,
+			ExpectedErr:   ErrInvalidAccount,
+		},
+		{
+			Name:          "unhealthy server",
+			Account:       testAcct,
+			HealthHandler: errHandler,
+			ExpectedErr:   &ClientError{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t)
+			mux.HandleFunc("/health", tc.HealthHandler)
+			mux.HandleFunc("/update", func(http.ResponseWriter, *http.Request) {
+				t.Error("unexpected call to the update endpoint")
+			})
+
+			err := client.UpdateTXTRecordDryRun(context.Background(), tc.Account)
+
+			var cErr *ClientError
+
+			switch expected := tc.ExpectedErr.(type) {
+			case nil:
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+			case *ClientError:
+				if !errors.As(err, &cErr) {
+					t.Errorf("expected ClientError, got %v", err)
+				}
+			default:
+				if !errors.Is(err, expected) {
+					t.Errorf("expected error %v, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func healthHandler(resp http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		resp.WriteHeader(http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+}
+
 func errHandler(resp http.ResponseWriter, _ *http.Request) {
 	resp.WriteHeader(http.StatusBadRequest)
 	_, _ = resp.Write(errBody)
@@ -230,7 +1810,7 @@ func updateTXTHandler(t *testing.T) http.HandlerFunc {
 	}
 }
 
-func setupTest(t *testing.T) (*Client, *http.ServeMux) {
+func setupTest(t *testing.T, opts ...Option) (*Client, *http.ServeMux) {
 	t.Helper()
 
 	mux := http.NewServeMux()
@@ -238,7 +1818,7 @@ func setupTest(t *testing.T) (*Client, *http.ServeMux) {
 	ts := httptest.NewServer(mux)
 	t.Cleanup(ts.Close)
 
-	client, _ := NewClient(ts.URL)
+	client, _ := NewClient(ts.URL, opts...)
 
 	return client, mux
 }
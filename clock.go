@@ -0,0 +1,34 @@
+package goacmedns
+
+import "time"
+
+// Clock provides the current time and timers to the client,
+// so that the time-based logic (e.g. `Retry-After` waits, DNS polling) can be tested without waiting.
+// The timeouts of [WithCallTimeout] and of the HTTP client use the real time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+// WithClock sets the clock used by the client.
+// The default is the real clock.
+func WithClock(clock Clock) Option {
+	return func(c *Client) {
+		if c != nil && clock != nil {
+			c.clock = clock
+		}
+	}
+}
+
+// realClock is the default [Clock], backed by the [time] package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,89 @@
+package goacmedns
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a [Clock] whose time only advances when waiting on it, without actually sleeping.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	f.waits = append(f.waits, d)
+
+	ch := make(chan time.Time, 1)
+	ch <- f.now
+
+	return ch
+}
+
+func TestWithClock_retryAfter(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		RetryAfter    string
+		ExpectedWaits []time.Duration
+	}{
+		{
+			Name:          "seconds",
+			RetryAfter:    "30",
+			ExpectedWaits: []time.Duration{30 * time.Second, 30 * time.Second},
+		},
+		{
+			Name:          "HTTP date",
+			RetryAfter:    "Mon, 01 Jan 2024 12:00:20 GMT",
+			ExpectedWaits: []time.Duration{20 * time.Second, 0},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			clock := newFakeClock()
+
+			handler, calls := newRateLimitedHandler(t, tc.RetryAfter, 2)
+
+			client, mux := setupTest(t, WithClock(clock))
+			mux.HandleFunc("/register", handler)
+
+			start := time.Now()
+
+			_, err := client.RegisterAccount(context.Background(), []string{"192.168.100.1/24"})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if *calls != 3 {
+				t.Errorf("expected 3 calls, got %d", *calls)
+			}
+
+			if !reflect.DeepEqual(clock.waits, tc.ExpectedWaits) {
+				t.Errorf("expected waits %v, got %v", tc.ExpectedWaits, clock.waits)
+			}
+
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("expected no real wait, took %s", elapsed)
+			}
+		})
+	}
+}
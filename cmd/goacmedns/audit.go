@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+var errAuditFailed = errors.New("storage audit failed")
+
+func auditCmd(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+
+	storagePath := fs.String("storage", "", "Path to the JSON storage file to audit")
+
+	_ = fs.Parse(args)
+
+	err := requireFlag("storage", *storagePath)
+	if err != nil {
+		return err
+	}
+
+	return audit(*storagePath, stdout)
+}
+
+// audit prints the JSON audit report of the storage file, and returns an error if the report has errors.
+func audit(storagePath string, stdout io.Writer) error {
+	st, err := loadStorage(storagePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	return auditStorage(ctx, st, stdout)
+}
+
+func auditStorage(ctx context.Context, st goacmedns.Storage, stdout io.Writer) error {
+	accounts, err := st.FetchAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch accounts from storage: %w", err)
+	}
+
+	report := storage.Audit(accounts)
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit report: %w", err)
+	}
+
+	if report.HasErrors() {
+		return fmt.Errorf("%w: %d error(s), %d warning(s)", errAuditFailed, report.Errors, report.Warnings)
+	}
+
+	return nil
+}
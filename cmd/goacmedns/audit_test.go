@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+func TestAudit(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Path        string
+		ExpectedErr error
+	}{
+		{
+			Name: "valid storage",
+			Path: filepath.Join("..", "..", "storage", "testdata", "accounts.json"),
+		},
+		{
+			Name:        "storage with errors",
+			Path:        filepath.Join("..", "..", "storage", "testdata", "audit_accounts.json"),
+			ExpectedErr: errAuditFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+
+			err := audit(tc.Path, stdout)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			var report storage.AuditReport
+
+			err = json.Unmarshal(stdout.Bytes(), &report)
+			if err != nil {
+				t.Fatalf("expected valid JSON report, got %q: %v", stdout.String(), err)
+			}
+
+			if report.HasErrors() != (tc.ExpectedErr != nil) {
+				t.Errorf("unexpected report %#v", report)
+			}
+		})
+	}
+}
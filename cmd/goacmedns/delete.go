@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+)
+
+func deleteCmd(args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+
+	domain := fs.String("domain", "", "Domain to delete the account of")
+	storagePath := fs.String("storage", "", "Path to the JSON storage file to update")
+
+	_ = fs.Parse(args)
+
+	err := errors.Join(
+		requireFlag("domain", *domain),
+		requireFlag("storage", *storagePath),
+	)
+	if err != nil {
+		return err
+	}
+
+	return deleteAccount(*domain, *storagePath)
+}
+
+func deleteAccount(domain, storagePath string) error {
+	st, err := loadStorage(storagePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	err = st.Delete(ctx, domain)
+	if err != nil {
+		return fmt.Errorf("failed to delete account for %q: %w", domain, err)
+	}
+
+	err = st.Save(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save storage: %w", err)
+	}
+
+	log.Printf("account for %q deleted from storage", domain)
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+func TestDeleteAccount(t *testing.T) {
+	ctx := context.Background()
+
+	storagePath := filepath.Join(t.TempDir(), "accounts.json")
+
+	st := storage.NewFile(storagePath, 0o600)
+
+	for _, domain := range []string{"example.com", "example.org"} {
+		err := st.Put(ctx, domain, goacmedns.Account{
+			FullDomain: "sub.auth.example.org",
+			SubDomain:  "sub",
+			Username:   "user",
+			Password:   "secret",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := st.Save(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = deleteAccount("example.com", storagePath)
+	if err != nil {
+		t.Fatalf("unexpected error deleting account: %v", err)
+	}
+
+	st = storage.NewFile(storagePath, 0o600)
+
+	_, err = st.Fetch(ctx, "example.com")
+	if !errors.Is(err, storage.ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound for deleted domain, got %v", err)
+	}
+
+	_, err = st.Fetch(ctx, "example.org")
+	if err != nil {
+		t.Errorf("unexpected error fetching remaining domain: %v", err)
+	}
+
+	err = deleteAccount("example.com", storagePath)
+	if !errors.Is(err, storage.ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound deleting a missing domain, got %v", err)
+	}
+}
+
+func TestDeleteAccount_missingStorage(t *testing.T) {
+	err := deleteAccount("example.com", filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil {
+		t.Error("expected an error for a missing storage file, got nil")
+	}
+}
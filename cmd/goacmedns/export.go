@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+func exportCmd(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+
+	storagePath := fs.String("storage", "", "Path to the JSON storage file to export")
+	outputPath := fs.String("output", "", "Path to the JSON lines file to write (default: stdout)")
+
+	_ = fs.Parse(args)
+
+	err := requireFlag("storage", *storagePath)
+	if err != nil {
+		return err
+	}
+
+	return exportAccounts(*storagePath, *outputPath, stdout)
+}
+
+func exportAccounts(storagePath, outputPath string, stdout io.Writer) (err error) {
+	st, err := loadStorage(storagePath)
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+
+	if outputPath != "" {
+		file, errOpen := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if errOpen != nil {
+			return fmt.Errorf("could not create output file: %w", errOpen)
+		}
+
+		defer func() { err = errors.Join(err, file.Close()) }()
+
+		w = file
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	count, err := storage.Export(ctx, w, st)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("%d accounts exported", count)
+
+	return nil
+}
+
+func importCmd(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+
+	storagePath := fs.String("storage", "", "Path to the JSON storage file to import into (created if required)")
+	inputPath := fs.String("input", "", "Path to the JSON lines file written by the export subcommand")
+	overwrite := fs.Bool("overwrite", false, "Replace the accounts already present in the storage file")
+
+	_ = fs.Parse(args)
+
+	err := errors.Join(
+		requireFlag("storage", *storagePath),
+		requireFlag("input", *inputPath),
+	)
+	if err != nil {
+		return err
+	}
+
+	return importAccounts(*storagePath, *inputPath, *overwrite)
+}
+
+func importAccounts(storagePath, inputPath string, overwrite bool) error {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("could not read input file: %w", err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	st, err := storage.OpenFile(storagePath, 0o600)
+	if err != nil {
+		return fmt.Errorf("could not load storage file: %w", err)
+	}
+
+	count, err := storage.Import(ctx, file, st, overwrite)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("%d accounts imported into %s", count, storagePath)
+
+	return nil
+}
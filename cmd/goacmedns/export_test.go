@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+
+	storagePath := filepath.Join(dir, "accounts.json")
+
+	st := storage.NewFile(storagePath, 0o600)
+
+	expected := map[string]goacmedns.Account{
+		"example.com": {
+			FullDomain: "sub.auth.example.org",
+			SubDomain:  "sub",
+			Username:   "user",
+			Password:   "secret",
+		},
+		"example.org": {
+			FullDomain: "other.auth.example.org",
+			SubDomain:  "other",
+			Username:   "user2",
+			Password:   "secret2",
+		},
+	}
+
+	for domain, acct := range expected {
+		err := st.Put(ctx, domain, acct)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := st.Save(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := new(bytes.Buffer)
+
+	err = execute([]string{"export", "-storage", storagePath}, stdout)
+	if err != nil {
+		t.Fatalf("unexpected error exporting accounts: %v", err)
+	}
+
+	backupPath := filepath.Join(dir, "accounts.jsonl")
+
+	err = os.WriteFile(backupPath, stdout.Bytes(), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restoredPath := filepath.Join(dir, "restored.json")
+
+	err = execute([]string{"import", "-storage", restoredPath, "-input", backupPath}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error importing accounts: %v", err)
+	}
+
+	accounts, _ := storage.NewFile(restoredPath, 0o600).FetchAll(ctx)
+	if !reflect.DeepEqual(accounts, expected) {
+		t.Errorf("expected accounts %v, got %v", expected, accounts)
+	}
+}
+
+func TestImport_unreadableStorage(t *testing.T) {
+	dir := t.TempDir()
+
+	storagePath := filepath.Join(dir, "accounts.json")
+
+	content := []byte(`{"version":2,"accounts":{}}`)
+
+	err := os.WriteFile(storagePath, content, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputPath := filepath.Join(dir, "accounts.jsonl")
+
+	err = os.WriteFile(inputPath, []byte(`{"domain":"example.com","account":{"fulldomain":"sub.auth.example.org","subdomain":"sub","username":"user","password":"secret"}}`+"\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = importAccounts(storagePath, inputPath, false)
+	if !errors.Is(err, storage.ErrUnsupportedVersion) {
+		t.Fatalf("expected error %v, got %v", storage.ErrUnsupportedVersion, err)
+	}
+
+	data, err := os.ReadFile(storagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, content) {
+		t.Errorf("expected the storage file to be unchanged, got %s", data)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+func listCmd(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+
+	storagePath := fs.String("storage", "", "Path to the JSON storage file to read")
+	asJSON := fs.Bool("json", false, "Print the accounts map as JSON, with the passwords redacted")
+	showPasswords := fs.Bool("show-passwords", false, "Print the plaintext passwords in the JSON output")
+
+	_ = fs.Parse(args)
+
+	err := requireFlag("storage", *storagePath)
+	if err != nil {
+		return err
+	}
+
+	return list(*storagePath, listConfig{asJSON: *asJSON, showPasswords: *showPasswords}, stdout)
+}
+
+// listConfig holds the output options of the list command.
+type listConfig struct {
+	asJSON bool
+	// showPasswords prints the plaintext passwords in the JSON output instead of redacting them.
+	showPasswords bool
+}
+
+func list(storagePath string, cfg listConfig, stdout io.Writer) error {
+	st, err := loadStorage(storagePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	return printAccounts(ctx, st, cfg, stdout)
+}
+
+// printAccounts prints all the accounts of the storage, either as a table or as JSON.
+// The passwords are only printed in the JSON output, when showPasswords is set.
+func printAccounts(ctx context.Context, st goacmedns.Storage, cfg listConfig, stdout io.Writer) error {
+	accounts, err := st.FetchAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch accounts from storage: %w", err)
+	}
+
+	if cfg.asJSON {
+		if !cfg.showPasswords {
+			redacted := make(map[string]goacmedns.Account, len(accounts))
+			for domain, acct := range accounts {
+				redacted[domain] = acct.Redacted()
+			}
+
+			accounts = redacted
+		}
+
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+
+		err = encoder.Encode(accounts)
+		if err != nil {
+			return fmt.Errorf("failed to encode accounts: %w", err)
+		}
+
+		return nil
+	}
+
+	domains := make([]string, 0, len(accounts))
+	for domain := range accounts {
+		domains = append(domains, domain)
+	}
+
+	slices.Sort(domains)
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(tw, "DOMAIN\tFULLDOMAIN\tSUBDOMAIN")
+
+	for _, domain := range domains {
+		acct := accounts[domain]
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", domain, acct.FullDomain, acct.SubDomain)
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return fmt.Errorf("failed to write accounts table: %w", err)
+	}
+
+	return nil
+}
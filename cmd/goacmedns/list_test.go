@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+func setupListStorage(t *testing.T) (string, map[string]goacmedns.Account) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	storagePath := filepath.Join(t.TempDir(), "accounts.json")
+
+	accounts := map[string]goacmedns.Account{
+		"example.com": {
+			FullDomain: "sub.auth.example.org",
+			SubDomain:  "sub",
+			Username:   "user",
+			Password:   "secret",
+		},
+		"example.org": {
+			FullDomain: "other.auth.example.org",
+			SubDomain:  "other",
+			Username:   "user2",
+			Password:   "secret2",
+		},
+	}
+
+	st := storage.NewFile(storagePath, 0o600)
+
+	for domain, acct := range accounts {
+		err := st.Put(ctx, domain, acct)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := st.Save(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return storagePath, accounts
+}
+
+func TestList(t *testing.T) {
+	storagePath, _ := setupListStorage(t)
+
+	stdout := new(bytes.Buffer)
+
+	err := execute([]string{"list", "-storage", storagePath}, stdout)
+	if err != nil {
+		t.Fatalf("unexpected error listing accounts: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+
+	expected := [][]string{
+		{"DOMAIN", "FULLDOMAIN", "SUBDOMAIN"},
+		{"example.com", "sub.auth.example.org", "sub"},
+		{"example.org", "other.auth.example.org", "other"},
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got %q", len(expected), stdout.String())
+	}
+
+	for i, line := range lines {
+		if fields := strings.Fields(line); !reflect.DeepEqual(fields, expected[i]) {
+			t.Errorf("expected line %d to be %v, got %q", i, expected[i], line)
+		}
+	}
+
+	if strings.Contains(stdout.String(), "secret") {
+		t.Errorf("expected no password in the output, got %q", stdout.String())
+	}
+}
+
+func TestList_json(t *testing.T) {
+	storagePath, accounts := setupListStorage(t)
+
+	redacted := make(map[string]goacmedns.Account, len(accounts))
+	for domain, acct := range accounts {
+		redacted[domain] = acct.Redacted()
+	}
+
+	testCases := []struct {
+		Name     string
+		Args     []string
+		Expected map[string]goacmedns.Account
+	}{
+		{
+			Name:     "redacted passwords",
+			Args:     []string{"list", "-storage", storagePath, "-json"},
+			Expected: redacted,
+		},
+		{
+			Name:     "plaintext passwords",
+			Args:     []string{"list", "-storage", storagePath, "-json", "-show-passwords"},
+			Expected: accounts,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+
+			err := execute(tc.Args, stdout)
+			if err != nil {
+				t.Fatalf("unexpected error listing accounts: %v", err)
+			}
+
+			var listed map[string]goacmedns.Account
+
+			err = json.Unmarshal(stdout.Bytes(), &listed)
+			if err != nil {
+				t.Fatalf("expected valid JSON output, got %q: %v", stdout.String(), err)
+			}
+
+			if !reflect.DeepEqual(listed, tc.Expected) {
+				t.Errorf("expected accounts %v, got %v", tc.Expected, listed)
+			}
+		})
+	}
+}
@@ -1,80 +1,104 @@
 package main
 
 import (
-	"context"
-	"flag"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/nrdcg/goacmedns"
 	"github.com/nrdcg/goacmedns/storage"
 )
 
-func main() {
-	apiBase := flag.String("api", "", "ACME-DNS server API URL")
-	domain := flag.String("domain", "", "Domain to register an account for")
-	storagePath := flag.String("storage", "", "Path to the JSON storage file to create/update")
-	allowFrom := flag.String("allowFrom", "", "List of comma separated CIDR notation networks the account is allowed to be used from")
-
-	flag.Parse()
+var (
+	errMissingFlag = errors.New("missing required flag")
+	errInvalidMode = errors.New("invalid file mode")
+)
 
-	if *apiBase == "" {
-		log.Fatal("You must provide a non-empty -api flag")
+func main() {
+	err := execute(os.Args[1:], os.Stdout)
+	if err != nil {
+		log.Fatal(err)
 	}
+}
 
-	if *domain == "" {
-		log.Fatal("You must provide a non-empty -domain flag")
-	}
+// execute dispatches the command line arguments to the matching subcommand.
+// For backward compatibility, arguments that don't start with a subcommand name are handled by the `register` subcommand.
+func execute(args []string, stdout io.Writer) error {
+	cmd := "register"
 
-	if *storagePath == "" {
-		log.Fatal("You must provide a non-empty -storage flag")
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
 	}
 
-	var allowedNetworks []string
-	if *allowFrom != "" {
-		allowedNetworks = strings.Split(*allowFrom, ",")
+	switch cmd {
+	case "register":
+		return registerCmd(args, stdout)
+	case "list":
+		return listCmd(args, stdout)
+	case "delete":
+		return deleteCmd(args)
+	case "update":
+		return updateCmd(args)
+	case "audit":
+		return auditCmd(args, stdout)
+	case "export":
+		return exportCmd(args, stdout)
+	case "import":
+		return importCmd(args)
+	default:
+		return fmt.Errorf("unknown command %q, expected one of: register, list, delete, update, audit, export, import", cmd)
 	}
+}
 
-	err := run(*apiBase, *domain, *storagePath, allowedNetworks)
-	if err != nil {
-		log.Fatal(err)
+// requireFlag returns an error if the value of the named flag is empty.
+func requireFlag(name, value string) error {
+	if value == "" {
+		return fmt.Errorf("%w: -%s", errMissingFlag, name)
 	}
+
+	return nil
 }
 
-func run(apiBase, domain, storagePath string, allowedNetworks []string) error {
-	client, err := goacmedns.NewClient(apiBase)
+// newClient creates a client for the ACME-DNS server API URL.
+func newClient(apiBase string, opts ...goacmedns.Option) (*goacmedns.Client, error) {
+	client, err := goacmedns.NewClient(apiBase, opts...)
 	if err != nil {
-		return fmt.Errorf("could not create goacmedns client: %w", err)
+		return nil, fmt.Errorf("could not create goacmedns client: %w", err)
 	}
 
-	st := storage.NewFile(storagePath, 0o600)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	defer cancel()
+	return client, nil
+}
 
-	newAcct, err := client.RegisterAccount(ctx, allowedNetworks)
+// loadStorage loads the storage file at the given path, which must already exist.
+func loadStorage(storagePath string) (*storage.File, error) {
+	_, err := os.Stat(storagePath)
 	if err != nil {
-		return fmt.Errorf("failed to register account: %w", err)
+		return nil, fmt.Errorf("could not read storage file: %w", err)
 	}
 
-	// Save it
-	err = st.Put(ctx, domain, newAcct)
+	st, err := storage.OpenFile(storagePath, 0o600)
 	if err != nil {
-		return fmt.Errorf("failed to put account in storage: %w", err)
+		return nil, fmt.Errorf("could not load storage file: %w", err)
 	}
 
-	err = st.Save(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to save storage: %w", err)
+	return st, nil
+}
+
+// parseFileMode parses an octal file mode (e.g. 0640).
+// A warning is logged if the mode grants access to all users.
+func parseFileMode(value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > uint64(os.ModePerm) {
+		return 0, fmt.Errorf("%w: %q, expected octal permissions like 0600", errInvalidMode, value)
 	}
 
-	log.Printf(
-		"new account created for %q. "+
-			"To complete setup for %q you must provision the following CNAME in your DNS zone:\n"+
-			"%s CNAME %s.\n",
-		domain, domain, "_acme-challenge."+domain, newAcct.FullDomain)
+	if mode&0o007 != 0 {
+		log.Printf("warning: file mode %#o grants access to all users, the storage file contains secrets", mode)
+	}
 
-	return nil
+	return os.FileMode(mode), nil
 }
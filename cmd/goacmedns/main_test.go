@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+var errBackend = errors.New("backend unavailable")
+
+var _ goacmedns.Storage = errStorage{}
+
+// errStorage is a [goacmedns.Storage] whose operations always fail, like an unreachable remote backend.
+type errStorage struct{}
+
+func (errStorage) Save(context.Context) error {
+	return errBackend
+}
+
+func (errStorage) Put(context.Context, string, goacmedns.Account) error {
+	return errBackend
+}
+
+func (errStorage) Fetch(context.Context, string) (goacmedns.Account, error) {
+	return goacmedns.Account{}, errBackend
+}
+
+func (errStorage) FetchAll(context.Context) (map[string]goacmedns.Account, error) {
+	return nil, errBackend
+}
+
+func TestFetchAllError(t *testing.T) {
+	ctx := context.Background()
+
+	err := printAccounts(ctx, errStorage{}, listConfig{}, io.Discard)
+	if !errors.Is(err, errBackend) {
+		t.Errorf("expected list to return the storage error, got %v", err)
+	}
+
+	err = auditStorage(ctx, errStorage{}, io.Discard)
+	if !errors.Is(err, errBackend) {
+		t.Errorf("expected audit to return the storage error, got %v", err)
+	}
+}
+
+func TestExecute_defaultRegister(t *testing.T) {
+	server := setupRegisterServer(t)
+
+	storagePath := filepath.Join(t.TempDir(), "accounts.json")
+
+	// Without a subcommand name, the flags are handled by the register subcommand.
+	err := execute([]string{"-api", server.URL, "-domain", "example.com", "-storage", storagePath}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error registering account: %v", err)
+	}
+
+	st, err := storage.OpenFile(storagePath, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acct, err := st.Fetch(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("expected the account to be saved: %v", err)
+	}
+
+	if acct.FullDomain != "sub.auth.example.org" {
+		t.Errorf("expected full domain %q, got %q", "sub.auth.example.org", acct.FullDomain)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// defaultRegisterTimeout is the default deadline of the register command, including the retries.
+const defaultRegisterTimeout = 2 * time.Minute
+
+var errInvalidOutput = errors.New("invalid output format")
+
+// registerConfig holds the parameters of the register command.
+type registerConfig struct {
+	apiBase     string
+	domain      string
+	storagePath string
+	allowFrom   []string
+	output      string
+	mode        os.FileMode
+	// force registers a new account even if the storage already has one for the domain.
+	force bool
+
+	// retries and retryInterval configure the retries of the registration (see [goacmedns.WithRetries]).
+	retries       int
+	retryInterval time.Duration
+	// timeout is the deadline of the command (defaultRegisterTimeout if zero).
+	timeout time.Duration
+}
+
+// registerOutput is the structured output of the register command.
+type registerOutput struct {
+	Domain      string `json:"domain"`
+	FullDomain  string `json:"full_domain"`
+	SubDomain   string `json:"subdomain"`
+	CNAMEName   string `json:"cname_name"`
+	CNAMETarget string `json:"cname_target"`
+}
+
+func registerCmd(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("register", flag.ExitOnError)
+
+	apiBase := fs.String("api", "", "ACME-DNS server API URL")
+	domain := fs.String("domain", "", "Domain to register an account for")
+	storagePath := fs.String("storage", "", "Path to the JSON storage file to create/update")
+	allowFrom := fs.String("allowFrom", "", "List of comma separated CIDR notation networks the account is allowed to be used from")
+	output := fs.String("output", outputText, "Output format: text or json")
+	mode := fs.String("mode", "0600", "Octal file mode used when creating the storage file")
+	retries := fs.Int("retries", 0, "Number of retries of the registration when the server is unreachable or fails")
+	retryInterval := fs.Duration("retry-interval", time.Second, "Wait before the first retry, doubled for each of the next ones")
+	timeout := fs.Duration("timeout", defaultRegisterTimeout, "Deadline of the registration, including the retries")
+	force := fs.Bool("force", false, "Register a new account even if the storage already has one for the domain")
+
+	_ = fs.Parse(args)
+
+	err := errors.Join(
+		requireFlag("api", *apiBase),
+		requireFlag("domain", *domain),
+		requireFlag("storage", *storagePath),
+	)
+	if err != nil {
+		return err
+	}
+
+	if *output != outputText && *output != outputJSON {
+		return fmt.Errorf("%w: %q, expected %s or %s", errInvalidOutput, *output, outputText, outputJSON)
+	}
+
+	cfg := registerConfig{
+		apiBase:       *apiBase,
+		domain:        *domain,
+		storagePath:   *storagePath,
+		output:        *output,
+		retries:       *retries,
+		retryInterval: *retryInterval,
+		timeout:       *timeout,
+		force:         *force,
+	}
+
+	cfg.mode, err = parseFileMode(*mode)
+	if err != nil {
+		return err
+	}
+
+	if *allowFrom != "" {
+		cfg.allowFrom = strings.Split(*allowFrom, ",")
+	}
+
+	return register(cfg, stdout)
+}
+
+func register(cfg registerConfig, stdout io.Writer) error {
+	client, err := newClient(cfg.apiBase, goacmedns.WithRetries(cfg.retries, cfg.retryInterval))
+	if err != nil {
+		return err
+	}
+
+	st, err := storage.OpenFile(cfg.storagePath, cfg.mode)
+	if err != nil {
+		return fmt.Errorf("could not load storage file: %w", err)
+	}
+
+	timeout := cfg.timeout
+	if timeout == 0 {
+		timeout = defaultRegisterTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	existing, err := st.Fetch(ctx, cfg.domain)
+
+	switch {
+	case err == nil && !cfg.force:
+		log.Printf("an account already exists for %q, use -force to register a new one", cfg.domain)
+
+		return printSetup(cfg, existing, stdout)
+	case err != nil && !errors.Is(err, storage.ErrDomainNotFound):
+		return fmt.Errorf("failed to fetch account for %q: %w", cfg.domain, err)
+	}
+
+	newAcct, err := client.RegisterAccount(ctx, cfg.allowFrom)
+	if err != nil {
+		var cErr *goacmedns.ClientError
+		if errors.As(err, &cErr) {
+			log.Printf("last server response (HTTP %d): %s", cErr.HTTPStatus, cErr.Body)
+		}
+
+		return fmt.Errorf("failed to register account: %w", err)
+	}
+
+	// Save it
+	err = st.Put(ctx, cfg.domain, newAcct)
+	if err != nil {
+		return fmt.Errorf("failed to put account in storage: %w", err)
+	}
+
+	err = st.Save(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save storage: %w", err)
+	}
+
+	log.Printf("new account created for %q.", cfg.domain)
+
+	return printSetup(cfg, newAcct, stdout)
+}
+
+// printSetup prints the CNAME record to provision to complete the setup of the account,
+// and the structured output when requested.
+func printSetup(cfg registerConfig, acct goacmedns.Account, stdout io.Writer) error {
+	instructions := acct.SetupInstructions(cfg.domain)
+
+	log.Printf(
+		"To complete setup for %q you must provision the following CNAME in your DNS zone:\n"+
+			"%s CNAME %s\n",
+		cfg.domain, instructions.Name, instructions.Target)
+
+	if cfg.output != outputJSON {
+		return nil
+	}
+
+	err := json.NewEncoder(stdout).Encode(registerOutput{
+		Domain:      cfg.domain,
+		FullDomain:  acct.FullDomain,
+		SubDomain:   acct.SubDomain,
+		CNAMEName:   instructions.Name,
+		CNAMETarget: instructions.Target,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	return nil
+}
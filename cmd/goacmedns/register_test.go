@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+func setupRegisterServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, _ *http.Request) {
+		resp.WriteHeader(http.StatusCreated)
+
+		_ = json.NewEncoder(resp).Encode(goacmedns.Account{
+			FullDomain: "sub.auth.example.org",
+			SubDomain:  "sub",
+			Username:   "user",
+			Password:   "secret",
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRegister_outputJSON(t *testing.T) {
+	server := setupRegisterServer(t)
+
+	stdout := new(bytes.Buffer)
+
+	err := register(registerConfig{
+		apiBase:     server.URL,
+		domain:      "example.com",
+		storagePath: filepath.Join(t.TempDir(), "accounts.json"),
+		output:      outputJSON,
+		mode:        0o600,
+	}, stdout)
+	if err != nil {
+		t.Fatalf("unexpected error registering account: %v", err)
+	}
+
+	var output registerOutput
+
+	err = json.Unmarshal(stdout.Bytes(), &output)
+	if err != nil {
+		t.Fatalf("expected valid JSON output, got %q: %v", stdout.String(), err)
+	}
+
+	expected := registerOutput{
+		Domain:      "example.com",
+		FullDomain:  "sub.auth.example.org",
+		SubDomain:   "sub",
+		CNAMEName:   "_acme-challenge.example.com",
+		CNAMETarget: "sub.auth.example.org.",
+	}
+
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("expected output %#v, got %#v", expected, output)
+	}
+}
+
+func TestRegister_outputText(t *testing.T) {
+	server := setupRegisterServer(t)
+
+	stdout := new(bytes.Buffer)
+
+	err := register(registerConfig{
+		apiBase:     server.URL,
+		domain:      "example.com",
+		storagePath: filepath.Join(t.TempDir(), "accounts.json"),
+		output:      outputText,
+		mode:        0o600,
+	}, stdout)
+	if err != nil {
+		t.Fatalf("unexpected error registering account: %v", err)
+	}
+
+	if stdout.Len() != 0 {
+		t.Errorf("expected nothing on stdout, got %q", stdout.String())
+	}
+}
+
+func TestRegister_mode(t *testing.T) {
+	server := setupRegisterServer(t)
+
+	mode, err := parseFileMode("0640")
+	if err != nil {
+		t.Fatalf("unexpected error parsing mode: %v", err)
+	}
+
+	storagePath := filepath.Join(t.TempDir(), "accounts.json")
+
+	err = register(registerConfig{
+		apiBase:     server.URL,
+		domain:      "example.com",
+		storagePath: storagePath,
+		output:      outputText,
+		mode:        mode,
+	}, io.Discard)
+	if err != nil {
+		t.Fatalf("unexpected error registering account: %v", err)
+	}
+
+	info, err := os.Stat(storagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("expected file mode %#o, got %#o", 0o640, info.Mode().Perm())
+	}
+}
+
+func setupFlakyRegisterServer(t *testing.T, failures int) (*httptest.Server, *int) {
+	t.Helper()
+
+	var calls int
+
+	register := setupRegisterServer(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		calls++
+
+		if calls <= failures {
+			resp.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = resp.Write([]byte("server warming up"))
+
+			return
+		}
+
+		register.Config.Handler.ServeHTTP(resp, req)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func TestRegister_retries(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Failures      int
+		ExpectedCalls int
+		ExpectedErr   bool
+	}{
+		{
+			Name:          "recovers",
+			Failures:      2,
+			ExpectedCalls: 3,
+		},
+		{
+			Name:          "retries exhausted",
+			Failures:      10,
+			ExpectedCalls: 4,
+			ExpectedErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			server, calls := setupFlakyRegisterServer(t, tc.Failures)
+
+			logs := new(bytes.Buffer)
+
+			log.SetOutput(logs)
+			t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+			err := execute([]string{
+				"register",
+				"-api", server.URL,
+				"-domain", "example.com",
+				"-storage", filepath.Join(t.TempDir(), "accounts.json"),
+				"-retries", "3",
+				"-retry-interval", "1ms",
+			}, io.Discard)
+
+			if *calls != tc.ExpectedCalls {
+				t.Errorf("expected %d calls, got %d", tc.ExpectedCalls, *calls)
+			}
+
+			if !tc.ExpectedErr {
+				if err != nil {
+					t.Errorf("unexpected error registering account: %v", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+
+			if !strings.Contains(logs.String(), "server warming up") {
+				t.Errorf("expected the last server response body to be printed, got %q", logs.String())
+			}
+		})
+	}
+}
+
+func TestRegister_existing(t *testing.T) {
+	var calls int
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, _ *http.Request) {
+		calls++
+
+		resp.WriteHeader(http.StatusCreated)
+
+		_ = json.NewEncoder(resp).Encode(goacmedns.Account{
+			FullDomain: fmt.Sprintf("sub%d.auth.example.org", calls),
+			SubDomain:  fmt.Sprintf("sub%d", calls),
+			Username:   "user",
+			Password:   "secret",
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	cfg := registerConfig{
+		apiBase:     server.URL,
+		domain:      "example.com",
+		storagePath: filepath.Join(t.TempDir(), "accounts.json"),
+		output:      outputJSON,
+		mode:        0o600,
+	}
+
+	testCases := []struct {
+		Name               string
+		Force              bool
+		ExpectedCalls      int
+		ExpectedFullDomain string
+	}{
+		{Name: "new account", ExpectedCalls: 1, ExpectedFullDomain: "sub1.auth.example.org"},
+		{Name: "existing account", ExpectedCalls: 1, ExpectedFullDomain: "sub1.auth.example.org"},
+		{Name: "forced registration", Force: true, ExpectedCalls: 2, ExpectedFullDomain: "sub2.auth.example.org"},
+	}
+
+	// The steps run in order against the same storage.
+	for _, tc := range testCases {
+		stdout := new(bytes.Buffer)
+
+		cfg.force = tc.Force
+
+		err := register(cfg, stdout)
+		if err != nil {
+			t.Fatalf("%s: unexpected error registering account: %v", tc.Name, err)
+		}
+
+		if calls != tc.ExpectedCalls {
+			t.Errorf("%s: expected %d registrations, got %d", tc.Name, tc.ExpectedCalls, calls)
+		}
+
+		var output registerOutput
+
+		err = json.Unmarshal(stdout.Bytes(), &output)
+		if err != nil {
+			t.Fatalf("%s: expected valid JSON output, got %q: %v", tc.Name, stdout.String(), err)
+		}
+
+		if output.FullDomain != tc.ExpectedFullDomain || output.CNAMETarget != tc.ExpectedFullDomain+"." {
+			t.Errorf("%s: expected the setup of %q, got %#v", tc.Name, tc.ExpectedFullDomain, output)
+		}
+
+		acct, err := storage.NewFile(cfg.storagePath, 0o600).Fetch(context.Background(), "example.com")
+		if err != nil {
+			t.Fatalf("%s: unexpected error fetching account: %v", tc.Name, err)
+		}
+
+		if acct.FullDomain != tc.ExpectedFullDomain {
+			t.Errorf("%s: expected the stored account of %q, got %q", tc.Name, tc.ExpectedFullDomain, acct.FullDomain)
+		}
+	}
+}
+
+func TestRegister_unreadableStorage(t *testing.T) {
+	server := setupRegisterServer(t)
+
+	storagePath := filepath.Join(t.TempDir(), "accounts.json")
+
+	// A storage file written by a newer version must not be overwritten.
+	content := []byte(`{"version":2,"accounts":{}}`)
+
+	err := os.WriteFile(storagePath, content, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := registerConfig{
+		apiBase:     server.URL,
+		domain:      "example.com",
+		storagePath: storagePath,
+		mode:        0o600,
+	}
+
+	err = register(cfg, io.Discard)
+	if !errors.Is(err, storage.ErrUnsupportedVersion) {
+		t.Fatalf("expected error %v, got %v", storage.ErrUnsupportedVersion, err)
+	}
+
+	data, err := os.ReadFile(storagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, content) {
+		t.Errorf("expected the storage file to be unchanged, got %s", data)
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	testCases := []struct {
+		Value        string
+		ExpectedMode os.FileMode
+		ExpectedErr  error
+	}{
+		{Value: "0600", ExpectedMode: 0o600},
+		{Value: "640", ExpectedMode: 0o640},
+		{Value: "0644", ExpectedMode: 0o644},
+		{Value: "0999", ExpectedErr: errInvalidMode},
+		{Value: "17777", ExpectedErr: errInvalidMode},
+		{Value: "rw-r-----", ExpectedErr: errInvalidMode},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Value, func(t *testing.T) {
+			mode, err := parseFileMode(tc.Value)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			if mode != tc.ExpectedMode {
+				t.Errorf("expected mode %#o, got %#o", tc.ExpectedMode, mode)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+// txtValueLength is the length of the TXT values accepted by ACME-DNS (a base64url encoded SHA-256 digest).
+const txtValueLength = 43
+
+var errInvalidTXTValue = errors.New("invalid TXT value")
+
+func updateCmd(args []string) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+
+	apiBase := fs.String("api", "", "ACME-DNS server API URL (defaults to the server URL of the stored account)")
+	domain := fs.String("domain", "", "Domain to update the TXT record for")
+	storagePath := fs.String("storage", "", "Path to the JSON storage file to read")
+	value := fs.String("value", "", "TXT record value to set")
+
+	_ = fs.Parse(args)
+
+	err := errors.Join(
+		requireFlag("domain", *domain),
+		requireFlag("storage", *storagePath),
+		requireFlag("value", *value),
+	)
+	if err != nil {
+		return err
+	}
+
+	return update(*apiBase, *domain, *storagePath, *value)
+}
+
+func update(apiBase, domain, storagePath, value string) error {
+	if len(value) != txtValueLength {
+		return fmt.Errorf("%w: expected %d characters, got %d", errInvalidTXTValue, txtValueLength, len(value))
+	}
+
+	st, err := loadStorage(storagePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	acct, err := st.Fetch(ctx, domain)
+	if err != nil {
+		return fmt.Errorf("failed to fetch account for %q: %w", domain, err)
+	}
+
+	client, err := newUpdateClient(apiBase, acct)
+	if err != nil {
+		return err
+	}
+
+	err = client.UpdateTXTRecord(ctx, acct, value)
+	if err != nil {
+		return err
+	}
+
+	err = storage.MarkUsed(ctx, st, domain, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record the use of the account for %q: %w", domain, err)
+	}
+
+	log.Printf("TXT record of %q (%s) updated to %q", domain, acct.FullDomain, value)
+
+	return nil
+}
+
+// newUpdateClient creates a client for the ACME-DNS server API URL if provided,
+// otherwise for the server URL of the account.
+func newUpdateClient(apiBase string, acct goacmedns.Account) (*goacmedns.Client, error) {
+	if apiBase != "" {
+		return newClient(apiBase)
+	}
+
+	client, err := goacmedns.NewClientForAccount(acct)
+	if errors.Is(err, goacmedns.ErrMissingServerURL) {
+		return nil, fmt.Errorf("%w: you must provide a non-empty -api flag", err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not create goacmedns client: %w", err)
+	}
+
+	return client, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+func TestUpdate(t *testing.T) {
+	value := strings.Repeat("a", txtValueLength)
+
+	var received goacmedns.Update
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		if user := req.Header.Get("X-Api-User"); user != "user" {
+			t.Errorf("expected X-Api-User %q got %q", "user", user)
+		}
+
+		err := json.NewDecoder(req.Body).Decode(&received)
+		if err != nil {
+			t.Errorf("error decoding request body JSON: %v", err)
+		}
+
+		_, _ = resp.Write([]byte(`{}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	storagePath := filepath.Join(t.TempDir(), "accounts.json")
+
+	st := storage.NewFile(storagePath, 0o600)
+
+	err := st.Put(context.Background(), "example.com", goacmedns.Account{
+		FullDomain: "sub.auth.example.org",
+		SubDomain:  "sub",
+		Username:   "user",
+		Password:   "secret",
+		ServerURL:  server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = st.Save(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = update("", "example.com", storagePath, value)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT record: %v", err)
+	}
+
+	if received.SubDomain != "sub" || received.Txt != value {
+		t.Errorf("unexpected update request %#v", received)
+	}
+
+	acct, _ := storage.NewFile(storagePath, 0o600).Fetch(context.Background(), "example.com")
+	if acct.LastUsedAt.IsZero() {
+		t.Error("expected the account to be marked as used")
+	}
+
+	err = update("", "example.com", storagePath, "too-short")
+	if !errors.Is(err, errInvalidTXTValue) {
+		t.Errorf("expected errInvalidTXTValue, got %v", err)
+	}
+
+	err = update("", "example.org", storagePath, value)
+	if !errors.Is(err, storage.ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound, got %v", err)
+	}
+}
+
+func TestUpdate_legacyAccount(t *testing.T) {
+	storagePath := filepath.Join(t.TempDir(), "accounts.json")
+
+	st := storage.NewFile(storagePath, 0o600)
+
+	err := st.Put(context.Background(), "example.com", goacmedns.Account{
+		FullDomain: "sub.auth.example.org",
+		SubDomain:  "sub",
+		Username:   "user",
+		Password:   "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = st.Save(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = update("", "example.com", storagePath, strings.Repeat("a", txtValueLength))
+	if !errors.Is(err, goacmedns.ErrMissingServerURL) {
+		t.Errorf("expected ErrMissingServerURL, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrCNAMEUnsupported is returned by [Client.VerifyCNAME] when the [Resolver] of the client can't resolve CNAME records.
+var ErrCNAMEUnsupported = errors.New("resolver doesn't support CNAME lookups")
+
+// CNAMEResolver is implemented by the [Resolver] able to resolve CNAME records, like [net.Resolver].
+type CNAMEResolver interface {
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// VerifyCNAME reports whether the CNAME record delegating the ACME DNS-01 challenge of the domain
+// (`_acme-challenge.<domain>`, see [Account.CNAMERecord]) targets the account's FullDomain,
+// e.g. to check that the operator created it before requesting a certificate.
+// A missing record reports false without error.
+// The record is resolved with the [Resolver] of the client (see [WithResolver]), which must implement [CNAMEResolver].
+// As [net.Resolver.LookupCNAME] follows the CNAME chains, a chain ending at the FullDomain is accepted.
+func (c *Client) VerifyCNAME(ctx context.Context, domain string, account Account) (bool, error) {
+	resolver, ok := c.resolver.(CNAMEResolver)
+	if !ok {
+		return false, fmt.Errorf("%w: %T", ErrCNAMEUnsupported, c.resolver)
+	}
+
+	name, target := account.CNAMERecord(domain)
+
+	cname, err := resolver.LookupCNAME(ctx, name)
+	if isNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to resolve the CNAME record of %q: %w", name, err)
+	}
+
+	return strings.EqualFold(strings.TrimSuffix(cname, "."), strings.TrimSuffix(target, ".")), nil
+}
@@ -0,0 +1,82 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+// cnameStubResolver is a [stubResolver] also resolving CNAME records.
+type cnameStubResolver struct {
+	stubResolver
+
+	cname map[string]string
+}
+
+func (r cnameStubResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if host == "_acme-challenge.servfail.example.com" {
+		return "", &net.DNSError{Err: "server misbehaving", Name: host, IsTemporary: true}
+	}
+
+	cname, ok := r.cname[host]
+	if !ok {
+		return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+
+	return cname, nil
+}
+
+func TestClient_VerifyCNAME(t *testing.T) {
+	resolver := cnameStubResolver{cname: map[string]string{
+		"_acme-challenge.example.com":     "tossed.auth.example.org.",
+		"_acme-challenge.www.example.com": "TOSSED.auth.example.org",
+		"_acme-challenge.other.com":       "other.auth.example.org.",
+	}}
+
+	account := Account{FullDomain: "tossed.auth.example.org"}
+
+	testCases := []struct {
+		Name        string
+		Domain      string
+		Expected    bool
+		ExpectedErr bool
+	}{
+		{Name: "correct", Domain: "example.com", Expected: true},
+		{Name: "wildcard", Domain: "*.example.com", Expected: true},
+		{Name: "case and trailing dot", Domain: "www.example.com", Expected: true},
+		{Name: "incorrect target", Domain: "other.com"},
+		{Name: "missing record", Domain: "missing.com"},
+		{Name: "lookup failure", Domain: "servfail.example.com", ExpectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, err := NewClient("https://auth.example.org", WithResolver(resolver))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ok, err := client.VerifyCNAME(context.Background(), tc.Domain, account)
+			if (err != nil) != tc.ExpectedErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if ok != tc.Expected {
+				t.Errorf("expected %t, got %t", tc.Expected, ok)
+			}
+		})
+	}
+}
+
+func TestClient_VerifyCNAME_unsupported(t *testing.T) {
+	client, err := NewClient("https://auth.example.org", WithResolver(stubResolver{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.VerifyCNAME(context.Background(), "example.com", testAcct)
+	if !errors.Is(err, ErrCNAMEUnsupported) {
+		t.Errorf("expected ErrCNAMEUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package goacmedns
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidContentType is returned by [NewClient] when the content type provided with [WithContentType] is not supported.
+var ErrInvalidContentType = errors.New("invalid content type")
+
+// ErrUnsupportedPayload is returned when a request payload can't be encoded with the content type of the client,
+// e.g. a custom [Client.RegisterAccountRaw] payload with [ContentTypeForm].
+var ErrUnsupportedPayload = errors.New("unsupported payload")
+
+// ContentType is the encoding of the request bodies sent to the ACME-DNS server.
+type ContentType string
+
+const (
+	// ContentTypeJSON encodes the request bodies as JSON (default).
+	ContentTypeJSON ContentType = "application/json"
+	// ContentTypeForm encodes the request bodies as URL-encoded forms,
+	// for the gateways not accepting JSON bodies.
+	// The form fields are named like the JSON fields (e.g. `subdomain` and `txt` for an [Update]),
+	// and the `allowfrom` networks of a [Register] are repeated fields.
+	ContentTypeForm ContentType = "application/x-www-form-urlencoded"
+)
+
+// WithContentType sets the encoding of the request bodies (JSON by default).
+func WithContentType(contentType ContentType) Option {
+	return func(c *Client) {
+		if c == nil {
+			return
+		}
+
+		switch contentType {
+		case ContentTypeJSON, ContentTypeForm:
+			c.contentType = contentType
+		default:
+			c.optionErrs = append(c.optionErrs, fmt.Errorf("%w: %q", ErrInvalidContentType, contentType))
+		}
+	}
+}
+
+// formEncoder is implemented by the payloads that can be encoded as URL-encoded forms.
+type formEncoder interface {
+	formValues() url.Values
+}
+
+func (r *Register) formValues() url.Values {
+	values := url.Values{}
+
+	for _, allowFrom := range r.AllowFrom {
+		values.Add("allowfrom", allowFrom)
+	}
+
+	if r.SubDomain != "" {
+		values.Set("subdomain", r.SubDomain)
+	}
+
+	return values
+}
+
+func (u *Update) formValues() url.Values {
+	return url.Values{
+		"subdomain": {u.SubDomain},
+		"txt":       {u.Txt},
+	}
+}
+
+// encodePayload encodes the payload with the content type of the client.
+// The [url.Values] payloads are sent as is with [ContentTypeForm].
+func (c *Client) encodePayload(payload any) (io.Reader, error) {
+	if c.contentType != ContentTypeForm {
+		body, err := encodeJSON(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request JSON body: %w", err)
+		}
+
+		return bytes.NewReader(body), nil
+	}
+
+	switch p := payload.(type) {
+	case formEncoder:
+		return strings.NewReader(p.formValues().Encode()), nil
+	case url.Values:
+		return strings.NewReader(p.Encode()), nil
+	default:
+		return nil, fmt.Errorf("%w: %T can't be encoded as a form", ErrUnsupportedPayload, payload)
+	}
+}
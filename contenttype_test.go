@@ -0,0 +1,89 @@
+package goacmedns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestWithContentType_form(t *testing.T) {
+	client, mux := setupTest(t, WithContentType(ContentTypeForm))
+
+	var (
+		contentType string
+		body        string
+	)
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		contentType = req.Header.Get("Content-Type")
+
+		raw, _ := io.ReadAll(req.Body)
+		body = string(raw)
+
+		resp.WriteHeader(http.StatusOK)
+	})
+
+	err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT record: %v", err)
+	}
+
+	if contentType != string(ContentTypeForm) {
+		t.Errorf("expected Content-Type %q, got %q", ContentTypeForm, contentType)
+	}
+
+	expected := url.Values{"subdomain": {testAcct.SubDomain}, "txt": {updateValue}}.Encode()
+	if body != expected {
+		t.Errorf("expected body %q, got %q", expected, body)
+	}
+}
+
+func TestWithContentType_formRegister(t *testing.T) {
+	client, mux := setupTest(t, WithContentType(ContentTypeForm))
+
+	var form url.Values
+
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, req *http.Request) {
+		err := req.ParseForm()
+		if err != nil {
+			t.Errorf("unexpected error parsing form: %v", err)
+		}
+
+		form = req.PostForm
+
+		resp.WriteHeader(http.StatusCreated)
+
+		_ = json.NewEncoder(resp).Encode(testAcct)
+	})
+
+	allowFrom := []string{"192.168.100.0/24", "1.2.3.4/32"}
+
+	_, err := client.RegisterAccountWithSubdomain(context.Background(), allowFrom, "tossed")
+	if err != nil {
+		t.Fatalf("unexpected error registering account: %v", err)
+	}
+
+	expected := url.Values{"allowfrom": allowFrom, "subdomain": {"tossed"}}
+	if !reflect.DeepEqual(form, expected) {
+		t.Errorf("expected form %v, got %v", expected, form)
+	}
+}
+
+func TestWithContentType_errors(t *testing.T) {
+	_, err := NewClient("https://auth.example.org", WithContentType("text/plain"))
+	if !errors.Is(err, ErrInvalidContentType) {
+		t.Errorf("expected ErrInvalidContentType, got %v", err)
+	}
+
+	client, _ := setupTest(t, WithContentType(ContentTypeForm))
+
+	_, err = client.RegisterAccountRaw(context.Background(), map[string]string{"allowfrom": "1.2.3.4/32"})
+	if !errors.Is(err, ErrUnsupportedPayload) {
+		t.Errorf("expected ErrUnsupportedPayload, got %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package goacmedns
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// errTrailingData is returned by the strict decoding when the response body holds more than one JSON value.
+var errTrailingData = errors.New("unexpected data after the JSON value")
+
+// WithStrictDecoding makes the client reject the response bodies with fields unknown to the result type,
+// or with data after the JSON value, instead of ignoring them, e.g. to detect a server bug or a misconfigured proxy.
+// The returned error wraps the [ClientError] of the response and describes the unexpected data.
+// By default, the unknown fields are ignored, for the forward compatibility with newer servers.
+func WithStrictDecoding() Option {
+	return func(c *Client) {
+		if c != nil {
+			c.strictDecoding = true
+		}
+	}
+}
+
+// unmarshal decodes the JSON response body into the result, strictly if requested with [WithStrictDecoding].
+func (c *Client) unmarshal(raw []byte, result any) error {
+	if !c.strictDecoding {
+		return json.Unmarshal(raw, result)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(result)
+	if err != nil {
+		return err
+	}
+
+	if decoder.Decode(&json.RawMessage{}) != io.EOF {
+		return errTrailingData
+	}
+
+	return nil
+}
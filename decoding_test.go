@@ -0,0 +1,75 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithStrictDecoding(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Options     []Option
+		Body        string
+		ExpectedErr string
+		ExpectedTxt string
+	}{
+		{
+			Name:        "lenient, unknown field",
+			Body:        `{"txt":"value","ttl":60}`,
+			ExpectedTxt: "value",
+		},
+		{
+			Name:        "strict, known fields",
+			Options:     []Option{WithStrictDecoding()},
+			Body:        `{"txt":"value"}`,
+			ExpectedTxt: "value",
+		},
+		{
+			Name:        "strict, unknown field",
+			Options:     []Option{WithStrictDecoding()},
+			Body:        `{"txt":"value","ttl":60}`,
+			ExpectedErr: `unknown field "ttl"`,
+		},
+		{
+			Name:        "strict, trailing data",
+			Options:     []Option{WithStrictDecoding()},
+			Body:        `{"txt":"value"}{"txt":"other"}`,
+			ExpectedErr: errTrailingData.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t, tc.Options...)
+			mux.HandleFunc("/update", func(resp http.ResponseWriter, _ *http.Request) {
+				_, _ = resp.Write([]byte(tc.Body))
+			})
+
+			resp, err := client.UpdateTXTRecordResponse(context.Background(), testAcct, updateValue)
+
+			if tc.ExpectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if resp.Txt != tc.ExpectedTxt {
+					t.Errorf("expected TXT value %q, got %q", tc.ExpectedTxt, resp.Txt)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tc.ExpectedErr) {
+				t.Fatalf("expected an error containing %q, got %v", tc.ExpectedErr, err)
+			}
+
+			var cErr *ClientError
+			if !errors.As(err, &cErr) || string(cErr.Body) != tc.Body {
+				t.Errorf("expected a ClientError with the response body, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// dnsPort is the port used to query the authoritative nameservers.
+const dnsPort = "53"
+
+// defaultPollInterval is the delay between two checks of [Client.WaitForTXT].
+const defaultPollInterval = 2 * time.Second
+
+// ErrNoNameservers is returned when the authoritative nameservers of a domain can't be found.
+var ErrNoNameservers = errors.New("no authoritative nameservers found")
+
+// ErrInvalidNameserver is returned by [NewClient] when the address provided with [WithNameserver] is invalid.
+var ErrInvalidNameserver = errors.New("invalid nameserver address")
+
+// Resolver is the subset of [net.Resolver] used to find the authoritative nameservers of the domains.
+// Only the NS lookups are pluggable: the TXT records checked by [Client.WaitForTXT] are always queried
+// from the authoritative nameservers themselves (or from the nameserver set with [WithNameserver]),
+// as a recursive resolver could answer with a cached value.
+type Resolver interface {
+	LookupNS(ctx context.Context, name string) ([]*net.NS, error)
+}
+
+// WithResolver sets the [Resolver] used to find the authoritative nameservers of the domains (see [Client.WaitForTXT]),
+// e.g. a [net.Resolver] pointed at the resolver of a split-horizon DNS.
+// The default is [net.DefaultResolver].
+func WithResolver(resolver Resolver) Option {
+	return func(c *Client) {
+		if c != nil && resolver != nil {
+			c.resolver = resolver
+		}
+	}
+}
+
+// WithNameserver makes [Client.WaitForTXT] check the TXT records against the nameserver at the given address only,
+// instead of the authoritative nameservers of the domains, e.g. in air-gapped environments.
+// The address is a host or a host:port (port 53 by default).
+// The queries are sent over UDP, and retried over TCP when the responses are truncated.
+func WithNameserver(addr string) Option {
+	return func(c *Client) {
+		if c == nil {
+			return
+		}
+
+		if addr == "" {
+			c.optionErrs = append(c.optionErrs, fmt.Errorf("%w: empty address", ErrInvalidNameserver))
+
+			return
+		}
+
+		c.nameserver = addr
+	}
+}
+
+// nameserverResolver returns a [net.Resolver] sending all its queries to the nameserver at the given address.
+// The network chosen by the resolver is kept: UDP, then TCP when a response is truncated.
+func nameserverResolver(addr string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// nameserverAddr returns the address of the nameserver, adding the DNS port if it has none.
+func nameserverAddr(ns string) string {
+	if _, _, err := net.SplitHostPort(ns); err == nil {
+		return ns
+	}
+
+	return net.JoinHostPort(ns, dnsPort)
+}
+
+// AuthoritativeNameservers returns the sorted hostnames of the authoritative nameservers of the zone containing the domain.
+// The zone is found by walking up the labels of the domain until a NS record set is found.
+func (c *Client) AuthoritativeNameservers(ctx context.Context, domain string) ([]string, error) {
+	name := strings.TrimSuffix(domain, ".")
+
+	for name != "" {
+		records, err := c.resolver.LookupNS(ctx, name)
+		if err != nil && !isNotFound(err) {
+			return nil, fmt.Errorf("failed to lookup NS records of %q: %w", name, err)
+		}
+
+		if len(records) > 0 {
+			nameservers := make([]string, 0, len(records))
+			for _, record := range records {
+				nameservers = append(nameservers, strings.TrimSuffix(record.Host, "."))
+			}
+
+			slices.Sort(nameservers)
+
+			return nameservers, nil
+		}
+
+		_, name, _ = strings.Cut(name, ".")
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNoNameservers, domain)
+}
+
+// WaitForTXT waits until every authoritative nameserver of the fqdn returns the expected TXT value,
+// which matches how certificate authorities validate DNS challenges.
+// The fqdn is typically the [Account.FullDomain] of an account, as CNAME records aren't followed across zones.
+// When the client was created with [WithNameserver], only this nameserver is checked.
+// It returns an error when the context is done before all the nameservers have converged.
+func (c *Client) WaitForTXT(ctx context.Context, fqdn, value string) error {
+	nameservers := []string{c.nameserver}
+
+	if c.nameserver == "" {
+		var err error
+
+		nameservers, err = c.AuthoritativeNameservers(ctx, fqdn)
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		pending := c.pendingNameservers(ctx, nameservers, fqdn, value)
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("TXT record of %q not propagated to %s: %w",
+				fqdn, strings.Join(pending, ", "), ctx.Err())
+		case <-c.clock.After(c.pollInterval):
+		}
+	}
+}
+
+// pendingNameservers returns the nameservers that don't return the expected TXT value for the fqdn.
+func (c *Client) pendingNameservers(ctx context.Context, nameservers []string, fqdn, value string) []string {
+	var pending []string
+
+	for _, ns := range nameservers {
+		values, err := nameserverResolver(nameserverAddr(ns)).LookupTXT(ctx, fqdn)
+		if err != nil || !slices.Contains(values, value) {
+			pending = append(pending, ns)
+		}
+	}
+
+	return pending
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,317 @@
+package goacmedns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type stubResolver struct {
+	ns map[string][]string
+}
+
+func (r stubResolver) LookupNS(_ context.Context, name string) ([]*net.NS, error) {
+	hosts, ok := r.ns[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+
+	var records []*net.NS
+	for _, host := range hosts {
+		records = append(records, &net.NS{Host: host + "."})
+	}
+
+	return records, nil
+}
+
+func TestClient_AuthoritativeNameservers(t *testing.T) {
+	client, err := NewClient("https://auth.example.org", WithResolver(stubResolver{
+		ns: map[string][]string{
+			"auth.example.org": {"ns2.example.org", "ns1.example.org"},
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nameservers, err := client.AuthoritativeNameservers(context.Background(), "sub.auth.example.org.")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"ns1.example.org", "ns2.example.org"}
+	if !reflect.DeepEqual(nameservers, expected) {
+		t.Errorf("expected nameservers %v, got %v", expected, nameservers)
+	}
+
+	_, err = client.AuthoritativeNameservers(context.Background(), "example.com")
+	if !errors.Is(err, ErrNoNameservers) {
+		t.Errorf("expected ErrNoNameservers, got %v", err)
+	}
+}
+
+func TestClient_WaitForTXT(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		NS1         []string
+		NS2         []string
+		ExpectedErr error
+	}{
+		{
+			Name: "all nameservers converged",
+			NS1:  []string{"old", updateValue},
+			NS2:  []string{updateValue},
+		},
+		{
+			Name:        "nameservers returning different values",
+			NS1:         []string{updateValue},
+			NS2:         []string{"old"},
+			ExpectedErr: context.DeadlineExceeded,
+		},
+		{
+			Name:        "nameserver without record",
+			NS1:         []string{updateValue},
+			ExpectedErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			// The NS records point at the local nameservers, with their ports.
+			resolver := stubResolver{
+				ns: map[string][]string{
+					"auth.example.org": {
+						startDNSServer(t, "sub.auth.example.org.", tc.NS1, false),
+						startDNSServer(t, "sub.auth.example.org.", tc.NS2, false),
+					},
+				},
+			}
+
+			client, err := NewClient("https://auth.example.org", WithResolver(resolver), WithClock(newFakeClock()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			err = client.WaitForTXT(ctx, "sub.auth.example.org", updateValue)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
+
+func TestWithResolver(t *testing.T) {
+	client, err := NewClient("https://auth.example.org", WithResolver(stubResolver{
+		ns: map[string][]string{"example.net": {"ns.example.net"}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nameservers, err := client.AuthoritativeNameservers(context.Background(), "sub.example.net")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"ns.example.net"}
+	if !reflect.DeepEqual(nameservers, expected) {
+		t.Errorf("expected nameservers %v, got %v", expected, nameservers)
+	}
+}
+
+func TestWithNameserver(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Values      []string
+		TruncateUDP bool
+		ExpectedErr error
+	}{
+		{
+			Name:   "UDP",
+			Values: []string{updateValue},
+		},
+		{
+			Name:        "TCP fallback",
+			Values:      []string{updateValue},
+			TruncateUDP: true,
+		},
+		{
+			Name:        "not propagated",
+			Values:      []string{"old"},
+			ExpectedErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			addr := startDNSServer(t, "sub.auth.example.org.", tc.Values, tc.TruncateUDP)
+
+			// The authoritative nameservers aren't looked up.
+			client, err := NewClient("https://auth.example.org",
+				WithNameserver(addr), WithResolver(stubResolver{}), WithClock(newFakeClock()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			err = client.WaitForTXT(ctx, "sub.auth.example.org", updateValue)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
+
+func TestWithNameserver_invalid(t *testing.T) {
+	_, err := NewClient("https://auth.example.org", WithNameserver(""))
+	if !errors.Is(err, ErrInvalidNameserver) {
+		t.Errorf("expected ErrInvalidNameserver, got %v", err)
+	}
+}
+
+func TestNameserverAddr(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		NS       string
+		Expected string
+	}{
+		{Name: "host", NS: "10.0.0.53", Expected: "10.0.0.53:53"},
+		{Name: "host and port", NS: "10.0.0.53:5353", Expected: "10.0.0.53:5353"},
+		{Name: "hostname", NS: "ns1.example.org", Expected: "ns1.example.org:53"},
+		{Name: "IPv6", NS: "2001:db8::53", Expected: "[2001:db8::53]:53"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if addr := nameserverAddr(tc.NS); addr != tc.Expected {
+				t.Errorf("expected address %q, got %q", tc.Expected, addr)
+			}
+		})
+	}
+}
+
+// startDNSServer starts a nameserver answering the TXT queries for the name with the values, over UDP and TCP,
+// and returns its address. When truncateUDP is true, the UDP responses are truncated, to force the use of TCP.
+func startDNSServer(t *testing.T, name string, values []string, truncateUDP bool) string {
+	t.Helper()
+
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = tcpListener.Close() })
+
+	udpConn, err := net.ListenPacket("udp", tcpListener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = udpConn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+
+		for {
+			n, from, err := udpConn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			_, _ = udpConn.WriteTo(dnsResponse(t, buf[:n], name, values, truncateUDP), from)
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := tcpListener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer func() { _ = conn.Close() }()
+
+				var length uint16
+
+				err := binary.Read(conn, binary.BigEndian, &length)
+				if err != nil {
+					return
+				}
+
+				query := make([]byte, length)
+
+				_, err = io.ReadFull(conn, query)
+				if err != nil {
+					return
+				}
+
+				resp := dnsResponse(t, query, name, values, false)
+
+				_ = binary.Write(conn, binary.BigEndian, uint16(len(resp)))
+				_, _ = conn.Write(resp)
+			}()
+		}
+	}()
+
+	return tcpListener.Addr().String()
+}
+
+// dnsResponse builds the response to the query, with the TXT values when the query is for the TXT records of the name.
+func dnsResponse(t *testing.T, query []byte, name string, values []string, truncate bool) []byte {
+	t.Helper()
+
+	var parser dnsmessage.Parser
+
+	header, err := parser.Start(query)
+	if err != nil {
+		t.Errorf("failed to parse DNS query: %v", err)
+
+		return nil
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		t.Errorf("failed to parse DNS question: %v", err)
+
+		return nil
+	}
+
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:            header.ID,
+			Response:      true,
+			Authoritative: true,
+			Truncated:     truncate,
+		},
+		Questions: []dnsmessage.Question{question},
+	}
+
+	if question.Type == dnsmessage.TypeTXT && question.Name.String() == name && !truncate {
+		for _, value := range values {
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET, TTL: 60},
+				Body:   &dnsmessage.TXTResource{TXT: []string{value}},
+			})
+		}
+	}
+
+	resp, err := msg.Pack()
+	if err != nil {
+		t.Errorf("failed to pack DNS response: %v", err)
+	}
+
+	return resp
+}
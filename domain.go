@@ -0,0 +1,33 @@
+package goacmedns
+
+import "context"
+
+// domainKey is the context key of the domain set with [WithDomainContext].
+type domainKey struct{}
+
+// WithDomainContext returns a copy of the context carrying the domain the calls of the client are made for,
+// so that the observability of a client serving many domains can be per-domain:
+// the domain is included in the log lines (see [WithLogger]), passed to the [DomainMetricsRecorder],
+// and can be read with [DomainFromContext] from the request context in the hooks (see [WithRequestHook]).
+// It doesn't change the requests sent to the server.
+func WithDomainContext(ctx context.Context, domain string) context.Context {
+	return context.WithValue(ctx, domainKey{}, domain)
+}
+
+// DomainFromContext returns the domain set with [WithDomainContext], if any.
+func DomainFromContext(ctx context.Context) (string, bool) {
+	domain, ok := ctx.Value(domainKey{}).(string)
+
+	return domain, ok && domain != ""
+}
+
+// debugf logs a debug line prefixed with the name of the library, and with the domain of the context (if any).
+func (c *Client) debugf(ctx context.Context, format string, args ...any) {
+	if domain, ok := DomainFromContext(ctx); ok {
+		c.logger.Debugf("goacmedns: [%s] "+format, append([]any{domain}, args...)...)
+
+		return
+	}
+
+	c.logger.Debugf("goacmedns: "+format, args...)
+}
@@ -0,0 +1,55 @@
+package goacmedns
+
+import "net/url"
+
+// EndpointPaths holds the paths of the ACME-DNS endpoints, relative to the base URL of the server.
+type EndpointPaths struct {
+	// Register is the path of the account registration endpoint (`register` by default).
+	Register string
+	// Update is the path of the TXT record update endpoint (`update` by default).
+	Update string
+	// Health is the path of the health endpoint (`health` by default).
+	Health string
+	// Rotate is the path of the password rotation endpoint (`rotate` by default), only supported by some ACME-DNS forks.
+	Rotate string
+}
+
+// DefaultEndpointPaths returns the paths of the endpoints of a standard ACME-DNS server.
+func DefaultEndpointPaths() EndpointPaths {
+	return EndpointPaths{
+		Register: "register",
+		Update:   "update",
+		Health:   "health",
+		Rotate:   "rotate",
+	}
+}
+
+// WithEndpointPaths remaps the paths of the endpoints, for the nonstandard deployments
+// (e.g. a gateway exposing the registration endpoint as `v1/accounts`).
+// The paths are relative to the base URL, and the empty paths keep their default value (see [DefaultEndpointPaths]).
+func WithEndpointPaths(paths EndpointPaths) Option {
+	return func(c *Client) {
+		if c == nil {
+			return
+		}
+
+		for _, p := range []struct {
+			dst *string
+			src string
+		}{
+			{dst: &c.paths.Register, src: paths.Register},
+			{dst: &c.paths.Update, src: paths.Update},
+			{dst: &c.paths.Health, src: paths.Health},
+			{dst: &c.paths.Rotate, src: paths.Rotate},
+		} {
+			if p.src != "" {
+				*p.dst = p.src
+			}
+		}
+	}
+}
+
+// endpoint returns the URL of the endpoint at the given path of the server.
+func (c *Client) endpoint(path string) *url.URL {
+	return c.baseURL.JoinPath(path)
+}
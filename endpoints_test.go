@@ -0,0 +1,52 @@
+package goacmedns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithEndpointPaths(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/acme-dns/v1/accounts", newRegHandler(t, nil))
+	mux.HandleFunc("/acme-dns/v1/txt", updateTXTHandler(t))
+	mux.HandleFunc("/acme-dns/health", healthHandler)
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	client, err := NewClient(ts.URL+"/acme-dns/", WithEndpointPaths(EndpointPaths{
+		Register: "v1/accounts",
+		Update:   "/v1/txt",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	ctx := context.Background()
+
+	_, err = client.RegisterAccount(ctx, nil)
+	if err != nil {
+		t.Errorf("unexpected error registering account on the remapped path: %v", err)
+	}
+
+	err = client.UpdateTXTRecord(ctx, testAcct, updateValue)
+	if err != nil {
+		t.Errorf("unexpected error updating TXT record on the remapped path: %v", err)
+	}
+
+	// The health path isn't remapped.
+	err = client.UpdateTXTRecordDryRun(ctx, testAcct)
+	if err != nil {
+		t.Errorf("unexpected error checking health on the default path: %v", err)
+	}
+}
+
+func TestWithEndpointPaths_defaults(t *testing.T) {
+	client, _ := setupTest(t, WithEndpointPaths(EndpointPaths{}))
+
+	if client.paths != DefaultEndpointPaths() {
+		t.Errorf("expected the default paths %v, got %v", DefaultEndpointPaths(), client.paths)
+	}
+}
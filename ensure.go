@@ -0,0 +1,94 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// EnsureAccount returns the account stored for the domain in the storage,
+// or registers a new account with the allowFrom networks and persists it in the storage when there is none.
+// The returned boolean reports whether a new account was registered.
+// This avoids registering several accounts for the same domain, orphaning the previous ones on the server.
+//
+// A [Storage.Fetch] failing with [ErrDomainNotFound] is confirmed with [Storage.FetchAll] before registering a new account.
+// The other errors of the storage are returned, as a registration would orphan the account the storage may hold.
+//
+// The concurrent calls for the same domain are deduplicated: only one of them checks the storage and registers,
+// the others wait for it and return its account (or error), with a false boolean.
+// The calls are keyed by domain only, so they should all use the same storage.
+// The shared registration isn't canceled with the context of the call which started it (only its values are kept),
+// so that the other calls don't fail with it: each call returns the error of its own context when it's done first,
+// while the registration goes on, bounded by the timeouts of the client.
+func (c *Client) EnsureAccount(ctx context.Context, store Storage, domain string, allowFrom []string) (Account, bool, error) {
+	// Set by the call starting ensureAccount, the other calls only share its result.
+	var leader bool
+
+	sharedCtx := context.WithoutCancel(ctx)
+
+	ch := c.ensureGroup.DoChan(domain, func() (any, error) {
+		leader = true
+
+		acct, created, err := c.ensureAccount(sharedCtx, store, domain, allowFrom)
+
+		return ensureResult{acct: acct, created: created}, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return Account{}, false, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return Account{}, false, res.Err
+		}
+
+		result, _ := res.Val.(ensureResult)
+
+		// The receive from the channel happens after the write of leader by the call starting ensureAccount.
+		return result.acct, result.created && leader, nil
+	}
+}
+
+// ensureResult is the result of ensureAccount shared by the deduplicated calls of [Client.EnsureAccount].
+type ensureResult struct {
+	acct    Account
+	created bool
+}
+
+// ensureAccount implements [Client.EnsureAccount], without deduplication.
+func (c *Client) ensureAccount(ctx context.Context, store Storage, domain string, allowFrom []string) (Account, bool, error) {
+	acct, err := store.Fetch(ctx, domain)
+	if err == nil {
+		return acct, false, nil
+	}
+
+	if !errors.Is(err, ErrDomainNotFound) {
+		return Account{}, false, fmt.Errorf("failed to fetch the account: %w", err)
+	}
+
+	accounts, err := store.FetchAll(ctx)
+	if err != nil {
+		return Account{}, false, fmt.Errorf("failed to fetch the accounts: %w", err)
+	}
+
+	if acct, found := accounts[domain]; found {
+		return acct, false, nil
+	}
+
+	acct, err = c.RegisterAccount(ctx, allowFrom)
+	if err != nil {
+		return Account{}, false, err
+	}
+
+	err = store.Put(ctx, domain, acct)
+	if err != nil {
+		return Account{}, false, fmt.Errorf("failed to put the account: %w", err)
+	}
+
+	err = store.Save(ctx)
+	if err != nil {
+		return Account{}, false, fmt.Errorf("failed to save the storage: %w", err)
+	}
+
+	return acct, true, nil
+}
@@ -0,0 +1,319 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memStorage is an in-memory [Storage].
+type memStorage struct {
+	mu       sync.Mutex
+	accounts map[string]Account
+	saves    int
+	err      error
+}
+
+func newMemStorage() *memStorage {
+	return &memStorage{accounts: make(map[string]Account)}
+}
+
+func (m *memStorage) Save(_ context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.saves++
+
+	return m.err
+}
+
+func (m *memStorage) Put(_ context.Context, domain string, account Account) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.accounts[domain] = account
+
+	return m.err
+}
+
+func (m *memStorage) Fetch(_ context.Context, domain string) (Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return Account{}, m.err
+	}
+
+	acct, found := m.accounts[domain]
+	if !found {
+		return Account{}, ErrDomainNotFound
+	}
+
+	return acct, nil
+}
+
+func (m *memStorage) FetchAll(_ context.Context) (map[string]Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return maps.Clone(m.accounts), m.err
+}
+
+func TestClient_EnsureAccount(t *testing.T) {
+	existing := Account{
+		FullDomain: "existing.auth.example.org",
+		SubDomain:  "existing",
+		Username:   "user",
+		Password:   "secret",
+		ServerURL:  "https://auth.example.org",
+	}
+
+	testCases := []struct {
+		Name            string
+		Domain          string
+		ExpectedCreated bool
+		ExpectedCalls   int
+	}{
+		{
+			Name:   "existing domain",
+			Domain: "existing.example.com",
+		},
+		{
+			Name:            "new domain",
+			Domain:          "new.example.com",
+			ExpectedCreated: true,
+			ExpectedCalls:   1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t)
+
+			var calls int
+
+			regHandler := newRegHandler(t, nil)
+
+			mux.HandleFunc("/register", func(resp http.ResponseWriter, req *http.Request) {
+				calls++
+
+				regHandler(resp, req)
+			})
+
+			store := newMemStorage()
+			store.accounts["existing.example.com"] = existing
+
+			acct, created, err := client.EnsureAccount(context.Background(), store, tc.Domain, nil)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if created != tc.ExpectedCreated {
+				t.Errorf("expected created to be %t, got %t", tc.ExpectedCreated, created)
+			}
+
+			if calls != tc.ExpectedCalls {
+				t.Errorf("expected %d registrations, got %d", tc.ExpectedCalls, calls)
+			}
+
+			if !reflect.DeepEqual(store.accounts[tc.Domain], acct) {
+				t.Errorf("expected the stored account %v, got %v", store.accounts[tc.Domain], acct)
+			}
+
+			if tc.ExpectedCreated && store.saves != 1 {
+				t.Errorf("expected the storage to be saved once, got %d", store.saves)
+			}
+
+			// A second call returns the same account.
+			again, created, err := client.EnsureAccount(context.Background(), store, tc.Domain, nil)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if created || !reflect.DeepEqual(again, acct) {
+				t.Errorf("expected the existing account %v, got %v (created: %t)", acct, again, created)
+			}
+		})
+	}
+}
+
+func TestClient_EnsureAccount_storageError(t *testing.T) {
+	client, mux := setupTest(t)
+
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, _ *http.Request) {
+		t.Error("expected no registration")
+
+		resp.WriteHeader(http.StatusInternalServerError)
+	})
+
+	errStorage := errors.New("storage unavailable")
+
+	store := newMemStorage()
+	store.err = errStorage
+
+	_, _, err := client.EnsureAccount(context.Background(), store, "example.com", nil)
+	if !errors.Is(err, errStorage) {
+		t.Errorf("expected error %v, got %v", errStorage, err)
+	}
+}
+
+// fetchErrStorage is a [memStorage] whose Fetch fails with err, while its other operations succeed.
+type fetchErrStorage struct {
+	*memStorage
+	err error
+}
+
+func (s fetchErrStorage) Fetch(context.Context, string) (Account, error) {
+	return Account{}, s.err
+}
+
+func TestClient_EnsureAccount_fetchError(t *testing.T) {
+	client, mux := setupTest(t)
+
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, _ *http.Request) {
+		t.Error("expected no registration")
+
+		resp.WriteHeader(http.StatusInternalServerError)
+	})
+
+	errFetch := errors.New("fetch timed out")
+
+	// The domain is missing from FetchAll too, which must not be used to confirm another error.
+	store := fetchErrStorage{memStorage: newMemStorage(), err: errFetch}
+
+	_, _, err := client.EnsureAccount(context.Background(), store, "example.com", nil)
+	if !errors.Is(err, errFetch) {
+		t.Errorf("expected error %v, got %v", errFetch, err)
+	}
+}
+
+func TestClient_EnsureAccount_concurrent(t *testing.T) {
+	client, mux := setupTest(t)
+
+	var calls atomic.Int32
+
+	regHandler := newRegHandler(t, nil)
+
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, req *http.Request) {
+		calls.Add(1)
+
+		// Gives the other calls the time to start while the registration is in flight.
+		time.Sleep(20 * time.Millisecond)
+
+		regHandler(resp, req)
+	})
+
+	store := newMemStorage()
+
+	const concurrency = 10
+
+	var (
+		wg       sync.WaitGroup
+		accounts [concurrency]Account
+		created  atomic.Int32
+	)
+
+	for i := range concurrency {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			acct, isNew, err := client.EnsureAccount(context.Background(), store, "example.com", nil)
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+
+			if isNew {
+				created.Add(1)
+			}
+
+			accounts[i] = acct
+		}()
+	}
+
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected 1 registration, got %d", n)
+	}
+
+	if n := created.Load(); n != 1 {
+		t.Errorf("expected 1 call to report a created account, got %d", n)
+	}
+
+	for i, acct := range accounts {
+		if !reflect.DeepEqual(acct, store.accounts["example.com"]) {
+			t.Errorf("call %d: expected the stored account %v, got %v", i, store.accounts["example.com"], acct)
+		}
+	}
+}
+
+func TestClient_EnsureAccount_canceledLeader(t *testing.T) {
+	client, mux := setupTest(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	regHandler := newRegHandler(t, nil)
+
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-release
+
+		regHandler(resp, req)
+	})
+
+	store := newMemStorage()
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+
+	leaderErr := make(chan error, 1)
+
+	go func() {
+		_, _, err := client.EnsureAccount(leaderCtx, store, "example.com", nil)
+		leaderErr <- err
+	}()
+
+	<-started
+
+	type result struct {
+		acct Account
+		err  error
+	}
+
+	waiter := make(chan result, 1)
+
+	go func() {
+		acct, _, err := client.EnsureAccount(context.Background(), store, "example.com", nil)
+		waiter <- result{acct: acct, err: err}
+	}()
+
+	cancel()
+
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the canceled call to return context.Canceled, got %v", err)
+	}
+
+	close(release)
+
+	res := <-waiter
+	if res.err != nil {
+		t.Fatalf("expected the waiting call to get the account, got %v", res.err)
+	}
+
+	stored, err := store.Fetch(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("expected the account to be stored, got %v", err)
+	}
+
+	if !reflect.DeepEqual(res.acct, stored) {
+		t.Errorf("expected the stored account %v, got %v", stored, res.acct)
+	}
+}
@@ -1,10 +1,65 @@
 package goacmedns
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// ErrInvalidBaseURL is returned by [NewClient] when the base URL isn't an absolute URL.
+var ErrInvalidBaseURL = errors.New("invalid base URL")
+
+// ErrInsecureBaseURL is returned by [NewClient] when the base URL (or the fallback URL) doesn't use HTTPS
+// while the client was created with [WithRequireHTTPS].
+var ErrInsecureBaseURL = errors.New("base URL must use HTTPS")
+
+// ErrInvalidProxyURL is returned by [NewClient] when the URL provided with [WithProxy] isn't a valid absolute URL.
+var ErrInvalidProxyURL = errors.New("invalid proxy URL")
+
+// ErrUnauthorized is returned by [Client.ValidateAccount] when the server rejects the account credentials.
+var ErrUnauthorized = errors.New("account credentials rejected by the server")
+
+// ErrResponseTooLarge is returned when a response body exceeds the limit set with [WithMaxResponseBytes].
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// ErrEmptyBody is returned when the server responds to a request expecting a JSON result with an empty body,
+// e.g. when a reverse proxy replaces the response.
+var ErrEmptyBody = errors.New("server returned empty body")
+
+// ErrAllowFromRequired is returned when registering an account without allowFrom networks
+// while the client was created with [WithRequireAllowFrom].
+var ErrAllowFromRequired = errors.New("allowFrom networks are required to register an account")
+
+// ErrInvalidAllowFrom is returned by [ValidateAllowFrom] when an allowFrom entry isn't a valid CIDR network.
+var ErrInvalidAllowFrom = errors.New("invalid allowFrom network")
+
+// ErrDomainNotFound is returned by the [Storage] implementations when the requested domain has no account.
+// The storage package exports it as storage.ErrDomainNotFound.
+var ErrDomainNotFound = errors.New("requested domain is not present in storage")
+
+// ErrInvalidAccount is returned by [Account.Validate] when an account is missing required fields.
+var ErrInvalidAccount = errors.New("invalid account")
+
+// ErrMissingServerURL is returned by [NewClientForAccount] when the account has no [Account.ServerURL],
+// e.g. an account registered before this field was added.
+var ErrMissingServerURL = errors.New("account has no server URL")
+
+// ErrMissingPassword is returned by [Client.RotatePassword] when the server response doesn't contain a new password.
+var ErrMissingPassword = errors.New("server response is missing the new password")
+
+// ErrUnsupportedByServer is returned when the server responds with a 404 or 405 status to a request
+// to an optional endpoint, not implemented by every ACME-DNS server (e.g. `/rotate`, `/health`).
+// The [ClientError] of the response is wrapped along with it.
+var ErrUnsupportedByServer = errors.New("endpoint not supported by the server")
 
 // ClientError represents an error from the ACME-DNS server.
 // It holds a [ClientError.Message] describing the operation the client was doing,
-// a [ClientError.HTTPStatus] code returned by the server, and the [ClientError.Body] of the HTTP Response from the server.
+// a [ClientError.HTTPStatus] code returned by the server, the [ClientError.Body] of the HTTP Response from the server,
+// and the [ClientError.Header] of the HTTP Response from the server.
+// When the server responds with an RFC 7807 `application/problem+json` body (e.g. from an API gateway),
+// its `title` and `detail` members are parsed into [ClientError.Title] and [ClientError.Detail].
 type ClientError struct {
 	// Message is a string describing the client operation that failed.
 	Message string
@@ -12,19 +67,54 @@ type ClientError struct {
 	HTTPStatus int
 	// Body is the response body the ACME DNS server returned.
 	Body []byte
+	// Header is the response header the ACME DNS server returned (e.g. to inspect `Retry-After`).
+	Header http.Header
+	// Title is the `title` of the problem details returned by the server, if any.
+	Title string
+	// Detail is the `detail` of the problem details returned by the server, if any.
+	Detail string
+}
+
+// problemDetails is the subset of the RFC 7807 problem details used by [ClientError].
+type problemDetails struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
 }
 
 // newClientError creates a ClientError instance populated with the given arguments.
-func newClientError(msg string, respCode int, respBody []byte) *ClientError {
-	return &ClientError{
+func newClientError(msg string, respCode int, respBody []byte, respHeader http.Header) *ClientError {
+	cErr := &ClientError{
 		Message:    msg,
 		HTTPStatus: respCode,
 		Body:       respBody,
+		Header:     respHeader,
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(respHeader.Get("Content-Type"))
+	if mediaType != "application/problem+json" {
+		return cErr
 	}
+
+	var problem problemDetails
+
+	if json.Unmarshal(respBody, &problem) == nil {
+		cErr.Title = problem.Title
+		cErr.Detail = problem.Detail
+	}
+
+	return cErr
 }
 
 // Error collects all the ClientError fields into a single string.
+// The problem details are used instead of the raw body when available.
 func (e ClientError) Error() string {
-	return fmt.Sprintf("%d: %s, response: %s",
-		e.HTTPStatus, e.Message, string(e.Body))
+	switch {
+	case e.Title != "" && e.Detail != "":
+		return fmt.Sprintf("%d: %s, problem: %s: %s", e.HTTPStatus, e.Message, e.Title, e.Detail)
+	case e.Title != "" || e.Detail != "":
+		return fmt.Sprintf("%d: %s, problem: %s%s", e.HTTPStatus, e.Message, e.Title, e.Detail)
+	default:
+		return fmt.Sprintf("%d: %s, response: %s",
+			e.HTTPStatus, e.Message, string(e.Body))
+	}
 }
@@ -0,0 +1,11 @@
+package goacmedns
+
+// Logger is used by the client to log debug information about its HTTP calls.
+type Logger interface {
+	Debugf(format string, args ...any)
+}
+
+// noopLogger is the default [Logger], it discards everything.
+type noopLogger struct{}
+
+func (noopLogger) Debugf(string, ...any) {}
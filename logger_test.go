@@ -0,0 +1,86 @@
+package goacmedns
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type capturingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *capturingLogger) Debugf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestWithLogger(t *testing.T) {
+	logger := &capturingLogger{}
+
+	client, mux := setupTest(t, WithLogger(logger))
+	mux.HandleFunc("/register", newRegHandler(t, nil))
+
+	_, err := client.RegisterAccount(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(logger.lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %q", len(logger.lines), logger.lines)
+	}
+
+	endpoint := client.baseURL.JoinPath("register").String()
+
+	expectedRequest := "goacmedns: request POST " + endpoint
+	if logger.lines[0] != expectedRequest {
+		t.Errorf("expected log line %q, got %q", expectedRequest, logger.lines[0])
+	}
+
+	expectedResponse := "goacmedns: response POST " + endpoint + ": 201 ("
+	if !strings.HasPrefix(logger.lines[1], expectedResponse) {
+		t.Errorf("expected log line starting with %q, got %q", expectedResponse, logger.lines[1])
+	}
+}
+
+func TestWithDomainContext(t *testing.T) {
+	logger := &capturingLogger{}
+
+	var hookDomain string
+
+	client, mux := setupTest(t, WithLogger(logger), WithRequestHook(func(req *http.Request) {
+		hookDomain, _ = DomainFromContext(req.Context())
+	}))
+	mux.HandleFunc("/update", updateTXTHandler(t))
+
+	ctx := WithDomainContext(context.Background(), "lettuceencrypt.org")
+
+	err := client.UpdateTXTRecord(ctx, testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(logger.lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %q", len(logger.lines), logger.lines)
+	}
+
+	expectedRequest := "goacmedns: [lettuceencrypt.org] request POST " + client.baseURL.JoinPath("update").String()
+	if logger.lines[0] != expectedRequest {
+		t.Errorf("expected log line %q, got %q", expectedRequest, logger.lines[0])
+	}
+
+	expectedResponse := "goacmedns: [lettuceencrypt.org] response POST "
+	if !strings.HasPrefix(logger.lines[1], expectedResponse) {
+		t.Errorf("expected log line starting with %q, got %q", expectedResponse, logger.lines[1])
+	}
+
+	if hookDomain != "lettuceencrypt.org" {
+		t.Errorf("expected the request hook to read the domain %q, got %q", "lettuceencrypt.org", hookDomain)
+	}
+}
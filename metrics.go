@@ -0,0 +1,66 @@
+package goacmedns
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MetricsRecorder records the calls of the client to the ACME-DNS server,
+// e.g. to export request counts and latencies with Prometheus.
+type MetricsRecorder interface {
+	// ObserveRequest is called once per call of the client (including its retries and fallback)
+	// with the endpoint path (e.g. `/register`, `/update`), the status code of the last response,
+	// or 0 if no response was received, and the total duration of the call.
+	ObserveRequest(endpoint string, status int, dur time.Duration)
+}
+
+// DomainMetricsRecorder is a [MetricsRecorder] also observing the domain of the calls, set with [WithDomainContext].
+// When the recorder given to [WithMetrics] implements it, ObserveDomainRequest is called instead of ObserveRequest.
+type DomainMetricsRecorder interface {
+	MetricsRecorder
+
+	// ObserveDomainRequest is like ObserveRequest, with the domain of the call (empty if not set).
+	ObserveDomainRequest(domain, endpoint string, status int, dur time.Duration)
+}
+
+// WithMetrics sets the [MetricsRecorder] observing the calls of the client.
+func WithMetrics(recorder MetricsRecorder) Option {
+	return func(c *Client) {
+		if c != nil {
+			c.metrics = recorder
+		}
+	}
+}
+
+// statusKey is the context key of the status code of the last response received for a call, recorded for the metrics.
+type statusKey struct{}
+
+// withStatusRecorder returns a copy of the request recording the status code of its responses into status.
+func withStatusRecorder(req *http.Request, status *int) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), statusKey{}, status))
+}
+
+// recordStatus records the status code of a response of the request (0 if none was received),
+// if requested by [withStatusRecorder].
+func recordStatus(req *http.Request, code int) {
+	if status, ok := req.Context().Value(statusKey{}).(*int); ok {
+		*status = code
+	}
+}
+
+// observe records the call to the metrics recorder.
+func (c *Client) observe(req *http.Request, status int, start time.Time) {
+	endpoint := strings.TrimPrefix(req.URL.Path, c.baseURL.Path)
+
+	if recorder, ok := c.metrics.(DomainMetricsRecorder); ok {
+		domain, _ := DomainFromContext(req.Context())
+
+		recorder.ObserveDomainRequest(domain, endpoint, status, time.Since(start))
+
+		return
+	}
+
+	c.metrics.ObserveRequest(endpoint, status, time.Since(start))
+}
@@ -0,0 +1,131 @@
+package goacmedns
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type observation struct {
+	Endpoint string
+	Status   int
+}
+
+// fakeRecorder is a [MetricsRecorder] keeping the observations.
+type fakeRecorder struct {
+	observations []observation
+}
+
+func (r *fakeRecorder) ObserveRequest(endpoint string, status int, dur time.Duration) {
+	if dur < 0 {
+		panic("negative duration")
+	}
+
+	r.observations = append(r.observations, observation{Endpoint: endpoint, Status: status})
+}
+
+func TestWithMetrics(t *testing.T) {
+	recorder := &fakeRecorder{}
+
+	client, mux := setupTest(t, WithMetrics(recorder))
+	mux.HandleFunc("/register", newRegHandler(t, nil))
+	mux.HandleFunc("/update", updateTXTHandler(t))
+
+	ctx := context.Background()
+
+	_, err := client.RegisterAccount(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error registering account: %v", err)
+	}
+
+	err = client.UpdateTXTRecord(ctx, testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT record: %v", err)
+	}
+
+	expected := []observation{
+		{Endpoint: "/register", Status: http.StatusCreated},
+		{Endpoint: "/update", Status: http.StatusOK},
+	}
+
+	if !reflect.DeepEqual(recorder.observations, expected) {
+		t.Errorf("expected observations %v, got %v", expected, recorder.observations)
+	}
+}
+
+func TestWithMetrics_errors(t *testing.T) {
+	recorder := &fakeRecorder{}
+
+	client, mux := setupTest(t, WithMetrics(recorder), WithRetries(1, 0))
+	mux.HandleFunc("/register", errHandler)
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, _ *http.Request) {
+		resp.WriteHeader(http.StatusInternalServerError)
+	})
+
+	ctx := context.Background()
+
+	_, err := client.RegisterAccount(ctx, nil)
+	assertStatus(t, err, http.StatusBadRequest)
+
+	err = client.UpdateTXTRecord(ctx, testAcct, updateValue)
+	assertStatus(t, err, http.StatusInternalServerError)
+
+	unreachable, _ := NewClient("http://127.0.0.1:1", WithMetrics(recorder))
+
+	err = unreachable.UpdateTXTRecord(ctx, testAcct, updateValue)
+	if err == nil {
+		t.Fatal("expected an error updating the TXT record on an unreachable server, got nil")
+	}
+
+	// The retried update is observed once.
+	expected := []observation{
+		{Endpoint: "/register", Status: http.StatusBadRequest},
+		{Endpoint: "/update", Status: http.StatusInternalServerError},
+		{Endpoint: "/update", Status: 0},
+	}
+
+	if !reflect.DeepEqual(recorder.observations, expected) {
+		t.Errorf("expected observations %v, got %v", expected, recorder.observations)
+	}
+}
+
+// fakeDomainRecorder is a [DomainMetricsRecorder] keeping the observed domains.
+type fakeDomainRecorder struct {
+	fakeRecorder
+
+	domains []string
+}
+
+func (r *fakeDomainRecorder) ObserveDomainRequest(domain, endpoint string, status int, dur time.Duration) {
+	r.domains = append(r.domains, domain)
+	r.ObserveRequest(endpoint, status, dur)
+}
+
+func TestWithMetrics_domain(t *testing.T) {
+	recorder := &fakeDomainRecorder{}
+
+	client, mux := setupTest(t, WithMetrics(recorder))
+	mux.HandleFunc("/update", updateTXTHandler(t))
+
+	err := client.UpdateTXTRecord(WithDomainContext(context.Background(), "lettuceencrypt.org"), testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT record: %v", err)
+	}
+
+	err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT record: %v", err)
+	}
+
+	expected := []string{"lettuceencrypt.org", ""}
+
+	if !reflect.DeepEqual(recorder.domains, expected) {
+		t.Errorf("expected domains %q, got %q", expected, recorder.domains)
+	}
+
+	if len(recorder.observations) != 2 {
+		t.Errorf("expected 2 observations, got %v", recorder.observations)
+	}
+}
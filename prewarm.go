@@ -0,0 +1,30 @@
+package goacmedns
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Prewarm opens a connection to the server, so that the next calls reuse it instead of paying for a new TLS handshake,
+// e.g. before a burst of registrations or updates.
+// It sends a request to the health endpoint: any response warms the connection, even if the server has no health endpoint.
+// The connection stays open as long as the idle connections of the HTTP client are kept (see [WithTransportConfig]).
+func (c *Client) Prewarm(ctx context.Context) error {
+	req, err := c.newRequest(ctx, http.MethodGet, c.endpoint(c.paths.Health), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to prewarm connection: %w", err)
+	}
+
+	// The body must be read until EOF for the connection to be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, c.maxResponseBytes))
+	_ = resp.Body.Close()
+
+	return nil
+}
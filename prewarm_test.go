@@ -0,0 +1,71 @@
+package goacmedns
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_Prewarm(t *testing.T) {
+	var conns, registrations atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", http.NotFound)
+	mux.HandleFunc("/update", updateTXTHandler(t))
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, req *http.Request) {
+		registrations.Add(1)
+		newRegHandler(t, nil)(resp, req)
+	})
+
+	ts := httptest.NewUnstartedServer(mux)
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+
+	client, err := NewClient(ts.URL, WithHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Prewarm(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error prewarming: %v", err)
+	}
+
+	if n := conns.Load(); n != 1 {
+		t.Errorf("expected 1 connection after prewarming, got %d", n)
+	}
+
+	if n := registrations.Load(); n != 0 {
+		t.Errorf("expected no registration, got %d", n)
+	}
+
+	err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT record: %v", err)
+	}
+
+	if n := conns.Load(); n != 1 {
+		t.Errorf("expected the prewarmed connection to be reused, got %d connections", n)
+	}
+}
+
+func TestClient_Prewarm_canceled(t *testing.T) {
+	client, _ := setupTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.Prewarm(ctx)
+	if err == nil {
+		t.Error("expected an error with a canceled context, got nil")
+	}
+}
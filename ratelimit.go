@@ -0,0 +1,47 @@
+package goacmedns
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrInvalidRateLimit is returned by [NewClient] when the rate or the burst provided with [WithRateLimit] isn't positive.
+var ErrInvalidRateLimit = errors.New("invalid rate limit")
+
+// WithRateLimit throttles the requests sent to the ACME-DNS server to `rps` requests per second on average,
+// allowing bursts of up to `burst` requests, e.g. to stay under the rate limits of the server during bulk operations.
+// Each request (including the retries) waits for its turn, or until its context is done.
+// The limit is shared by all the calls of the client.
+// [NewClient] returns an error wrapping [ErrInvalidRateLimit] if `rps` or `burst` isn't positive.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		if c == nil {
+			return
+		}
+
+		if rps <= 0 || burst <= 0 {
+			c.optionErrs = append(c.optionErrs, fmt.Errorf("%w: %g requests per second, burst of %d", ErrInvalidRateLimit, rps, burst))
+
+			return
+		}
+
+		c.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+}
+
+// waitRateLimit waits until the request can be sent according to the rate limit set with [WithRateLimit].
+func (c *Client) waitRateLimit(req *http.Request) error {
+	if c.limiter == nil {
+		return nil
+	}
+
+	err := c.limiter.Wait(req.Context())
+	if err != nil {
+		return fmt.Errorf("failed to wait for the rate limit: %w", errors.Join(err, req.Context().Err()))
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	client, mux := setupTest(t, WithRateLimit(20, 1))
+
+	var sent []time.Time
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		sent = append(sent, time.Now())
+
+		updateTXTHandler(t)(resp, req)
+	})
+
+	for range 5 {
+		err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+		if err != nil {
+			t.Fatalf("unexpected error updating TXT record: %v", err)
+		}
+	}
+
+	// With 20 requests per second, the requests are spaced by 50ms.
+	const minInterval = 40 * time.Millisecond
+
+	for i := 1; i < len(sent); i++ {
+		if interval := sent[i].Sub(sent[i-1]); interval < minInterval {
+			t.Errorf("expected request %d to be sent at least %s after the previous one, got %s", i, minInterval, interval)
+		}
+	}
+}
+
+func TestWithRateLimit_canceled(t *testing.T) {
+	client, mux := setupTest(t, WithRateLimit(0.1, 1))
+
+	var calls int
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		calls++
+
+		updateTXTHandler(t)(resp, req)
+	})
+
+	err := client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT record: %v", err)
+	}
+
+	// The next token is available in 10s.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = client.UpdateTXTRecord(ctx, testAcct, updateValue)
+	if err == nil {
+		t.Fatal("expected an error waiting for the rate limit, got nil")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestWithRateLimit_invalid(t *testing.T) {
+	for _, limit := range []struct {
+		rps   float64
+		burst int
+	}{{rps: 0, burst: 1}, {rps: -1, burst: 1}, {rps: 1, burst: 0}} {
+		_, err := NewClient("https://auth.example.org", WithRateLimit(limit.rps, limit.burst))
+		if !errors.Is(err, ErrInvalidRateLimit) {
+			t.Errorf("expected ErrInvalidRateLimit for %v, got %v", limit, err)
+		}
+	}
+}
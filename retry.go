@@ -0,0 +1,175 @@
+package goacmedns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultMaxRetryAfterWait is the default maximum total time waited on `Retry-After` headers for a request.
+	defaultMaxRetryAfterWait = time.Minute
+
+	// maxRetryInterval caps the exponential backoff between the retries of [WithRetries].
+	maxRetryInterval = time.Minute
+)
+
+// WithRetries makes the client retry the requests failing with a network error or a 5xx response, up to `retries` times,
+// e.g. when a new server is still warming up.
+// The client waits `interval` before the first retry, and doubles the wait before each of the next ones (up to 1 minute),
+// unless another strategy is set with [WithBackoffStrategy].
+// The rate-limited requests are retried separately (see [WithMaxRetryAfterWait]).
+// The registrations (see [Client.RegisterAccount]) are only retried on connection failures and 503 responses:
+// the other failures, like timeouts, may happen after the server created the account, and a retry would create another one.
+func WithRetries(retries int, interval time.Duration) Option {
+	return func(c *Client) {
+		if c != nil && retries >= 0 && interval >= 0 {
+			c.retries = retries
+			c.retryInterval = interval
+		}
+	}
+}
+
+// doWithRetries sends the request with [Client.doWithFallback], retrying it on server failures (see [WithRetries]).
+// It returns the base URL of the server that served the request.
+func (c *Client) doWithRetries(req *http.Request, result any) (*url.URL, error) {
+	served, err := c.doWithFallback(req, result)
+
+	for attempt := range c.retries {
+		if !isRetryable(req, err) {
+			break
+		}
+
+		delay := c.retryDelay(attempt)
+
+		c.debugf(req.Context(), "retrying %s %s in %s (%d/%d): %v", req.Method, req.URL, delay, attempt+1, c.retries, err)
+
+		errSleep := c.sleep(req.Context(), delay)
+		if errSleep != nil {
+			return nil, fmt.Errorf("failed to wait before retrying: %w", errors.Join(errSleep, err))
+		}
+
+		req, err = c.cloneRequest(req)
+		if err != nil {
+			return nil, err
+		}
+
+		served, err = c.doWithFallback(req, result)
+	}
+
+	return served, err
+}
+
+// nonIdempotentKey is the context key marking the requests that must not be sent twice (see [nonIdempotent]).
+type nonIdempotentKey struct{}
+
+// nonIdempotent returns a copy of the request only retried, or sent to the fallback server,
+// when the failure guarantees that the server didn't process it, e.g. a registration.
+func nonIdempotent(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), nonIdempotentKey{}, true))
+}
+
+// isRetryable reports whether the failed request can be retried, or sent to the fallback server:
+// the server failures, restricted to the failures of unprocessed requests for the requests marked with [nonIdempotent].
+func isRetryable(req *http.Request, err error) bool {
+	if !isServerFailure(req.Context(), err) {
+		return false
+	}
+
+	if marked, _ := req.Context().Value(nonIdempotentKey{}).(bool); !marked {
+		return true
+	}
+
+	return isUnprocessed(err)
+}
+
+// isUnprocessed reports whether the error guarantees that the server didn't process the request:
+// a 503 response (e.g. from a server still warming up), or a failure to connect to the server.
+func isUnprocessed(err error) bool {
+	var cErr *ClientError
+	if errors.As(err, &cErr) {
+		return cErr.HTTPStatus == http.StatusServiceUnavailable
+	}
+
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryDelay returns the wait before the retry following the given attempt,
+// computed by the strategy set with [WithBackoffStrategy], or with the default exponential backoff.
+func (c *Client) retryDelay(attempt int) time.Duration {
+	if c.backoffStrategy != nil {
+		return max(c.backoffStrategy.NextDelay(attempt), 0)
+	}
+
+	return backoff(c.retryInterval, attempt)
+}
+
+// backoff returns the wait before the retry following the given attempt (starting at 0), capped to [maxRetryInterval].
+func backoff(interval time.Duration, attempt int) time.Duration {
+	delay := interval
+
+	for range attempt {
+		delay *= 2
+
+		if delay >= maxRetryInterval {
+			return maxRetryInterval
+		}
+	}
+
+	return min(delay, maxRetryInterval)
+}
+
+// retryAfterDelay returns the delay to wait before retrying a rate-limited request (429 response).
+// It returns false if the response isn't rate-limited,
+// or if waiting would exceed the maximum total wait given the time already waited.
+func (c *Client) retryAfterDelay(resp *http.Response, waited time.Duration) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), c.clock.Now())
+	if !ok || waited+delay > c.maxRetryAfterWait {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+// parseRetryAfter parses the value of a `Retry-After` header, either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
+
+// sleep waits for the given duration on the client clock, or until the context is done.
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.clock.After(d):
+		return nil
+	}
+}
@@ -0,0 +1,276 @@
+package goacmedns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name          string
+		Value         string
+		ExpectedDelay time.Duration
+		ExpectedOK    bool
+	}{
+		{Name: "seconds", Value: "120", ExpectedDelay: 2 * time.Minute, ExpectedOK: true},
+		{Name: "zero seconds", Value: "0", ExpectedDelay: 0, ExpectedOK: true},
+		{Name: "HTTP date", Value: "Mon, 01 Jan 2024 12:00:30 GMT", ExpectedDelay: 30 * time.Second, ExpectedOK: true},
+		{Name: "past HTTP date", Value: "Mon, 01 Jan 2024 11:00:00 GMT", ExpectedDelay: 0, ExpectedOK: true},
+		{Name: "empty", Value: ""},
+		{Name: "negative seconds", Value: "-1"},
+		{Name: "invalid", Value: "soon"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			delay, ok := parseRetryAfter(tc.Value, now)
+
+			if ok != tc.ExpectedOK || delay != tc.ExpectedDelay {
+				t.Errorf("expected (%s, %t), got (%s, %t)", tc.ExpectedDelay, tc.ExpectedOK, delay, ok)
+			}
+		})
+	}
+}
+
+func newRateLimitedHandler(t *testing.T, retryAfter string, limited int) (http.HandlerFunc, *int) {
+	t.Helper()
+
+	var calls int
+
+	return func(resp http.ResponseWriter, req *http.Request) {
+		calls++
+
+		var regReq Register
+
+		err := json.NewDecoder(req.Body).Decode(&regReq)
+		if err != nil {
+			t.Errorf("error decoding request body JSON on call %d: %v", calls, err)
+		}
+
+		if calls <= limited {
+			resp.Header().Set("Retry-After", retryAfter)
+			resp.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		resp.WriteHeader(http.StatusCreated)
+
+		newRegBody, _ := json.Marshal(testAcct)
+		_, _ = resp.Write(newRegBody)
+	}, &calls
+}
+
+func TestClient_do_retryAfter(t *testing.T) {
+	handler, calls := newRateLimitedHandler(t, "0", 2)
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/register", handler)
+
+	_, err := client.RegisterAccount(context.Background(), []string{"192.168.100.1/24"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if *calls != 3 {
+		t.Errorf("expected 3 calls, got %d", *calls)
+	}
+}
+
+func TestClient_do_retryAfterExceedsMaxWait(t *testing.T) {
+	handler, calls := newRateLimitedHandler(t, "120", 1)
+
+	client, mux := setupTest(t, WithMaxRetryAfterWait(time.Minute))
+	mux.HandleFunc("/register", handler)
+
+	_, err := client.RegisterAccount(context.Background(), []string{"192.168.100.1/24"})
+
+	var cErr *ClientError
+	if !errors.As(err, &cErr) || cErr.HTTPStatus != http.StatusTooManyRequests {
+		t.Fatalf("expected a 429 ClientError, got %v", err)
+	}
+
+	if *calls != 1 {
+		t.Errorf("expected 1 call, got %d", *calls)
+	}
+}
+
+func TestClient_do_retryAfterContextDeadline(t *testing.T) {
+	handler, calls := newRateLimitedHandler(t, "30", 1)
+
+	client, mux := setupTest(t)
+	mux.HandleFunc("/register", handler)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.RegisterAccount(ctx, []string{"192.168.100.1/24"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if *calls != 1 {
+		t.Errorf("expected 1 call, got %d", *calls)
+	}
+}
+
+func newFlakyHandler(t *testing.T, failures int) (http.HandlerFunc, *int) {
+	t.Helper()
+
+	var calls int
+
+	return func(resp http.ResponseWriter, req *http.Request) {
+		calls++
+
+		if calls <= failures {
+			resp.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = resp.Write([]byte("warming up"))
+
+			return
+		}
+
+		newRegHandler(t, nil)(resp, req)
+	}, &calls
+}
+
+func TestWithRetries(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Failures       int
+		ExpectedCalls  int
+		ExpectedWaits  []time.Duration
+		ExpectedStatus int
+	}{
+		{
+			Name:          "no failure",
+			ExpectedCalls: 1,
+		},
+		{
+			Name:          "recovers",
+			Failures:      2,
+			ExpectedCalls: 3,
+			ExpectedWaits: []time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			Name:           "retries exhausted",
+			Failures:       10,
+			ExpectedCalls:  4,
+			ExpectedWaits:  []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+			ExpectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			clock := newFakeClock()
+
+			handler, calls := newFlakyHandler(t, tc.Failures)
+
+			client, mux := setupTest(t, WithRetries(3, time.Second), WithClock(clock))
+			mux.HandleFunc("/register", handler)
+
+			_, err := client.RegisterAccount(context.Background(), nil)
+			assertStatus(t, err, tc.ExpectedStatus)
+
+			if *calls != tc.ExpectedCalls {
+				t.Errorf("expected %d calls, got %d", tc.ExpectedCalls, *calls)
+			}
+
+			if !reflect.DeepEqual(clock.waits, tc.ExpectedWaits) {
+				t.Errorf("expected waits %v, got %v", tc.ExpectedWaits, clock.waits)
+			}
+		})
+	}
+}
+
+func TestWithRetries_clientError(t *testing.T) {
+	var calls int
+
+	client, mux := setupTest(t, WithRetries(3, time.Second), WithClock(newFakeClock()))
+	mux.HandleFunc("/register", func(resp http.ResponseWriter, req *http.Request) {
+		calls++
+
+		errHandler(resp, req)
+	})
+
+	_, err := client.RegisterAccount(context.Background(), nil)
+	assertStatus(t, err, http.StatusBadRequest)
+
+	if calls != 1 {
+		t.Errorf("expected the 4xx response not to be retried, got %d calls", calls)
+	}
+}
+
+func TestWithRetries_nonIdempotent(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Status        int
+		ExpectedCalls int
+	}{
+		{Name: "service unavailable", Status: http.StatusServiceUnavailable, ExpectedCalls: 4},
+		{Name: "internal server error", Status: http.StatusInternalServerError, ExpectedCalls: 1},
+		{Name: "gateway timeout", Status: http.StatusGatewayTimeout, ExpectedCalls: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, mux := setupTest(t, WithRetries(3, time.Second), WithClock(newFakeClock()))
+
+			var registerCalls, updateCalls int
+
+			mux.HandleFunc("/register", func(resp http.ResponseWriter, _ *http.Request) {
+				registerCalls++
+
+				resp.WriteHeader(tc.Status)
+			})
+
+			mux.HandleFunc("/update", func(resp http.ResponseWriter, _ *http.Request) {
+				updateCalls++
+
+				resp.WriteHeader(tc.Status)
+			})
+
+			// The registration may have created an account before failing.
+			_, err := client.RegisterAccount(context.Background(), nil)
+			assertStatus(t, err, tc.Status)
+
+			if registerCalls != tc.ExpectedCalls {
+				t.Errorf("expected %d registration calls, got %d", tc.ExpectedCalls, registerCalls)
+			}
+
+			// The updates are idempotent.
+			err = client.UpdateTXTRecord(context.Background(), testAcct, updateValue)
+			assertStatus(t, err, tc.Status)
+
+			if updateCalls != 4 {
+				t.Errorf("expected 4 update calls, got %d", updateCalls)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		Attempt       int
+		ExpectedDelay time.Duration
+	}{
+		{Attempt: 0, ExpectedDelay: time.Second},
+		{Attempt: 1, ExpectedDelay: 2 * time.Second},
+		{Attempt: 4, ExpectedDelay: 16 * time.Second},
+		{Attempt: 6, ExpectedDelay: time.Minute},
+		{Attempt: 100, ExpectedDelay: time.Minute},
+	}
+
+	for _, tc := range testCases {
+		if delay := backoff(time.Second, tc.Attempt); delay != tc.ExpectedDelay {
+			t.Errorf("expected delay %s for attempt %d, got %s", tc.ExpectedDelay, tc.Attempt, delay)
+		}
+	}
+}
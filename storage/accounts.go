@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"slices"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// LegacyDomains returns the sorted list of domains whose [goacmedns.Account] has an empty `ServerURL`.
+// Such accounts were registered before the `ServerURL` field was added to the storage format.
+func LegacyDomains(accounts map[string]goacmedns.Account) []string {
+	var domains []string
+
+	for domain, acct := range accounts {
+		if acct.ServerURL == "" {
+			domains = append(domains, domain)
+		}
+	}
+
+	slices.Sort(domains)
+
+	return domains
+}
+
+// StorageStats holds aggregates computed over the accounts of a storage.
+type StorageStats struct {
+	// Total is the number of accounts.
+	Total int
+	// PerServer is the number of accounts per `ServerURL`.
+	// Legacy accounts are counted under the empty string key.
+	PerServer map[string]int
+	// Legacy is the number of accounts without a `ServerURL` (see [LegacyDomains]).
+	Legacy int
+}
+
+// Stats computes the [StorageStats] of the given accounts.
+func Stats(accounts map[string]goacmedns.Account) StorageStats {
+	stats := StorageStats{
+		Total:     len(accounts),
+		PerServer: make(map[string]int),
+	}
+
+	for _, acct := range accounts {
+		stats.PerServer[acct.ServerURL]++
+
+		if acct.ServerURL == "" {
+			stats.Legacy++
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+func TestLegacyDomains(t *testing.T) {
+	fs := NewFile(filepath.Join("testdata", "mixed_accounts.json"), 0o600)
+
+	accounts, err := fs.FetchAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"example.com", "threeletter.agency"}
+
+	domains := LegacyDomains(accounts)
+
+	if !reflect.DeepEqual(domains, expected) {
+		t.Errorf("expected legacy domains %v, got %v", expected, domains)
+	}
+}
+
+func TestLegacyDomains_noLegacy(t *testing.T) {
+	domains := LegacyDomains(testAccounts)
+
+	if len(domains) != 0 {
+		t.Errorf("expected no legacy domains, got %v", domains)
+	}
+
+	domains = LegacyDomains(map[string]goacmedns.Account{})
+
+	if len(domains) != 0 {
+		t.Errorf("expected no legacy domains for empty accounts, got %v", domains)
+	}
+}
+
+func TestStats(t *testing.T) {
+	fs := NewFile(filepath.Join("testdata", "mixed_accounts.json"), 0o600)
+
+	accounts, err := fs.FetchAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := StorageStats{
+		Total: 3,
+		PerServer: map[string]int{
+			"":                         2,
+			"https://auth.acme-dns.io": 1,
+		},
+		Legacy: 2,
+	}
+
+	stats := Stats(accounts)
+
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats %#v, got %#v", expected, stats)
+	}
+}
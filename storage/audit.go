@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// Severity is the severity of an audit [Finding].
+type Severity string
+
+const (
+	// SeverityWarning is used for findings that don't prevent the account from being used.
+	SeverityWarning Severity = "warning"
+	// SeverityError is used for findings that prevent the account from being used.
+	SeverityError Severity = "error"
+)
+
+// FindingType identifies the check that produced an audit [Finding].
+type FindingType string
+
+const (
+	// FindingMissingField is reported when a required account field is empty.
+	FindingMissingField FindingType = "missing_field"
+	// FindingMissingPassword is reported for accounts without a password,
+	// which is expected when the password is loaded with [LoadAccountWithKeyFile].
+	FindingMissingPassword FindingType = "missing_password"
+	// FindingLegacy is reported for accounts without a `ServerURL` (see [LegacyDomains]).
+	FindingLegacy FindingType = "legacy"
+	// FindingSubDomainCollision is reported when several domains share the same subdomain on the same server.
+	FindingSubDomainCollision FindingType = "subdomain_collision"
+	// FindingInvalidServerURL is reported when the `ServerURL` isn't an absolute HTTP(S) URL.
+	FindingInvalidServerURL FindingType = "invalid_server_url"
+	// FindingMalformedFullDomain is reported when the `FullDomain` isn't a valid domain name.
+	FindingMalformedFullDomain FindingType = "malformed_full_domain"
+)
+
+// Finding is an issue found on an account by [Audit].
+type Finding struct {
+	Type     FindingType `json:"type"`
+	Severity Severity    `json:"severity"`
+	Message  string      `json:"message"`
+}
+
+// AuditReport is the result of an [Audit] of the accounts of a storage.
+type AuditReport struct {
+	// Accounts is the number of audited accounts.
+	Accounts int `json:"accounts"`
+	// Errors is the number of findings with [SeverityError].
+	Errors int `json:"errors"`
+	// Warnings is the number of findings with [SeverityWarning].
+	Warnings int `json:"warnings"`
+	// Findings holds the findings per domain. Domains without findings are omitted.
+	Findings map[string][]Finding `json:"findings"`
+}
+
+// HasErrors reports whether the report contains findings with [SeverityError].
+func (r AuditReport) HasErrors() bool {
+	return r.Errors > 0
+}
+
+func (r *AuditReport) add(domain string, finding Finding) {
+	r.Findings[domain] = append(r.Findings[domain], finding)
+
+	switch finding.Severity {
+	case SeverityError:
+		r.Errors++
+	case SeverityWarning:
+		r.Warnings++
+	}
+}
+
+// Audit runs all the consistency checks over the given accounts and returns a report of the findings per domain.
+func Audit(accounts map[string]goacmedns.Account) AuditReport {
+	report := AuditReport{
+		Accounts: len(accounts),
+		Findings: make(map[string][]Finding),
+	}
+
+	domains := make([]string, 0, len(accounts))
+	for domain := range accounts {
+		domains = append(domains, domain)
+	}
+
+	slices.Sort(domains)
+
+	for _, domain := range domains {
+		auditAccount(&report, domain, accounts[domain])
+	}
+
+	for _, domain := range LegacyDomains(accounts) {
+		report.add(domain, Finding{
+			Type:     FindingLegacy,
+			Severity: SeverityWarning,
+			Message:  "account has no server URL (legacy format)",
+		})
+	}
+
+	auditSubDomainCollisions(&report, domains, accounts)
+
+	return report
+}
+
+func auditAccount(report *AuditReport, domain string, acct goacmedns.Account) {
+	required := []struct {
+		name  string
+		value string
+	}{
+		{name: "fulldomain", value: acct.FullDomain},
+		{name: "subdomain", value: acct.SubDomain},
+		{name: "username", value: acct.Username},
+	}
+
+	for _, field := range required {
+		if field.value == "" {
+			report.add(domain, Finding{
+				Type:     FindingMissingField,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("missing %s", field.name),
+			})
+		}
+	}
+
+	if acct.Password == "" {
+		report.add(domain, Finding{
+			Type:     FindingMissingPassword,
+			Severity: SeverityWarning,
+			Message:  "missing password (it must be loaded from a key file)",
+		})
+	}
+
+	if acct.ServerURL != "" {
+		u, err := url.Parse(acct.ServerURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			report.add(domain, Finding{
+				Type:     FindingInvalidServerURL,
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("server URL %q is not an absolute HTTP(S) URL", acct.ServerURL),
+			})
+		}
+	}
+
+	if acct.FullDomain != "" && !isDomainName(acct.FullDomain) {
+		report.add(domain, Finding{
+			Type:     FindingMalformedFullDomain,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("full domain %q is not a valid domain name", acct.FullDomain),
+		})
+	}
+}
+
+func auditSubDomainCollisions(report *AuditReport, domains []string, accounts map[string]goacmedns.Account) {
+	type key struct {
+		serverURL string
+		subDomain string
+	}
+
+	owners := make(map[key][]string)
+
+	for _, domain := range domains {
+		acct := accounts[domain]
+		if acct.SubDomain == "" {
+			continue
+		}
+
+		k := key{serverURL: acct.ServerURL, subDomain: acct.SubDomain}
+		owners[k] = append(owners[k], domain)
+	}
+
+	for _, domain := range domains {
+		acct := accounts[domain]
+
+		shared := owners[key{serverURL: acct.ServerURL, subDomain: acct.SubDomain}]
+		if len(shared) < 2 {
+			continue
+		}
+
+		others := slices.DeleteFunc(slices.Clone(shared), func(d string) bool { return d == domain })
+
+		report.add(domain, Finding{
+			Type:     FindingSubDomainCollision,
+			Severity: SeverityError,
+			Message:  fmt.Sprintf("subdomain %q is also used by %s", acct.SubDomain, strings.Join(others, ", ")),
+		})
+	}
+}
+
+// isDomainName reports whether the name is a syntactically valid domain name.
+func isDomainName(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+
+	if name == "" || len(name) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, r := range label {
+			if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
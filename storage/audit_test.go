@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	fs := NewFile(filepath.Join("testdata", "audit_accounts.json"), 0o600)
+
+	accounts, err := fs.FetchAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Audit(accounts)
+
+	expected := map[string][]FindingType{
+		"legacy.example.com":        {FindingLegacy},
+		"keyfile.example.com":       {FindingMissingPassword},
+		"missing.example.com":       {FindingMissingField, FindingMissingField},
+		"collision-a.example.com":   {FindingSubDomainCollision},
+		"collision-b.example.com":   {FindingSubDomainCollision},
+		"badurl.example.com":        {FindingInvalidServerURL},
+		"badfulldomain.example.com": {FindingMalformedFullDomain},
+	}
+
+	if report.Accounts != len(accounts) {
+		t.Errorf("expected %d audited accounts, got %d", len(accounts), report.Accounts)
+	}
+
+	if len(report.Findings) != len(expected) {
+		t.Errorf("expected findings for %d domains, got %d: %#v", len(expected), len(report.Findings), report.Findings)
+	}
+
+	for domain, types := range expected {
+		var found []FindingType
+		for _, finding := range report.Findings[domain] {
+			found = append(found, finding.Type)
+		}
+
+		if !reflect.DeepEqual(found, types) {
+			t.Errorf("expected findings %v for %q, got %v", types, domain, found)
+		}
+	}
+
+	if report.Errors != 6 || report.Warnings != 2 {
+		t.Errorf("expected 6 errors and 2 warnings, got %d errors and %d warnings", report.Errors, report.Warnings)
+	}
+
+	if !report.HasErrors() {
+		t.Error("expected report to have errors")
+	}
+}
+
+func TestAudit_valid(t *testing.T) {
+	report := Audit(testAccounts)
+
+	if report.HasErrors() || report.Warnings != 0 || len(report.Findings) != 0 {
+		t.Errorf("expected no findings, got %#v", report)
+	}
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"encoding/json"
+
+	"github.com/nrdcg/goacmedns"
+	"gopkg.in/yaml.v3"
+)
+
+// codec defines how the accounts are serialized in the storage file.
+type codec struct {
+	name      string
+	marshal   func(v any) ([]byte, error)
+	unmarshal func(data []byte, v any) error
+}
+
+var (
+	jsonCodec = codec{name: "json", marshal: json.Marshal, unmarshal: json.Unmarshal}
+	yamlCodec = codec{name: "yaml", marshal: yaml.Marshal, unmarshal: yaml.Unmarshal}
+)
+
+// EncodeJSON returns the JSON content of a storage file holding the accounts, in the format written by [File.Save],
+// e.g. for the storage backends persisting the accounts as a single object.
+func EncodeJSON(accounts map[string]goacmedns.Account) ([]byte, error) {
+	return json.Marshal(fileContent{Version: fileVersion, Accounts: accounts})
+}
+
+// DecodeJSON returns the accounts of the JSON content of a storage file, in any format loaded by [File]:
+// the versioned format written by [File.Save], or the legacy format.
+// An error wrapping [ErrUnsupportedVersion] is returned for a newer format version than supported.
+func DecodeJSON(data []byte) (map[string]goacmedns.Account, error) {
+	accounts, err := decodeAccounts(jsonCodec, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if accounts == nil {
+		// The content is a null value.
+		accounts = make(map[string]goacmedns.Account)
+	}
+
+	return accounts, nil
+}
+
+// withCodec sets the codec used to serialize the accounts.
+func withCodec(c codec) FileOption {
+	return func(f *File) {
+		f.codec = c
+	}
+}
+
+// WithIndent makes [File.Save] write indented JSON (see [json.MarshalIndent]),
+// so that the storage file is human-readable and produces clean diffs.
+// The keys are always sorted, and both compact and indented files can be loaded.
+// It has no effect on the storage files created with [NewYAMLFile].
+func WithIndent(prefix, indent string) FileOption {
+	return func(f *File) {
+		if f.codec.name != jsonCodec.name {
+			return
+		}
+
+		f.codec.marshal = func(v any) ([]byte, error) {
+			return json.MarshalIndent(v, prefix, indent)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package consul provides a [goacmedns.Storage] implementation backed by the HashiCorp Consul KV store.
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+var _ goacmedns.Storage = (*Storage)(nil)
+
+// KV is the subset of the Consul KV API used by [Storage].
+// It is implemented by [api.KV], returned by [api.Client.KV].
+type KV interface {
+	Get(key string, q *api.QueryOptions) (*api.KVPair, *api.QueryMeta, error)
+	Put(p *api.KVPair, q *api.WriteOptions) (*api.WriteMeta, error)
+	List(prefix string, q *api.QueryOptions) (api.KVPairs, *api.QueryMeta, error)
+}
+
+// Storage implements the [goacmedns.Storage] interface and persists the accounts in the Consul KV store.
+// Each account is stored as JSON under the key `<prefix>/<domain>`.
+type Storage struct {
+	kv     KV
+	prefix string
+}
+
+// New returns a [Storage] persisting the accounts under the given `prefix` of the Consul KV store.
+func New(kv KV, prefix string) *Storage {
+	return &Storage{kv: kv, prefix: strings.TrimSuffix(prefix, "/") + "/"}
+}
+
+// Save is a no-op: the accounts are written to the KV store by [Storage.Put].
+func (s *Storage) Save(_ context.Context) error {
+	return nil
+}
+
+// Put writes the [goacmedns.Account] for the given `domain` to the KV store.
+// The [goacmedns.Account] is checked with [goacmedns.Account.Validate] before being written.
+func (s *Storage) Put(ctx context.Context, domain string, acct goacmedns.Account) error {
+	err := acct.Validate()
+	if err != nil {
+		return fmt.Errorf("account for %q: %w", domain, err)
+	}
+
+	value, err := json.Marshal(acct)
+	if err != nil {
+		return fmt.Errorf("failed to marshal account: %w", err)
+	}
+
+	_, err = s.kv.Put(&api.KVPair{Key: s.prefix + domain, Value: value}, (&api.WriteOptions{}).WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to put account for %q: %w", domain, err)
+	}
+
+	return nil
+}
+
+// Fetch retrieves the [goacmedns.Account] for the given `domain` from the KV store.
+// If the `domain` provided does not have a [goacmedns.Account] in the KV store an [storage.ErrDomainNotFound] error is returned.
+func (s *Storage) Fetch(ctx context.Context, domain string) (goacmedns.Account, error) {
+	pair, _, err := s.kv.Get(s.prefix+domain, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return goacmedns.Account{}, fmt.Errorf("failed to get account for %q: %w", domain, err)
+	}
+
+	if pair == nil {
+		return goacmedns.Account{}, storage.ErrDomainNotFound
+	}
+
+	var acct goacmedns.Account
+
+	err = json.Unmarshal(pair.Value, &acct)
+	if err != nil {
+		return goacmedns.Account{}, fmt.Errorf("failed to unmarshal account for %q: %w", domain, err)
+	}
+
+	return acct, nil
+}
+
+// FetchAll lists the keys under the prefix of the KV store,
+// and returns a map that has domain names as its keys and [goacmedns.Account] objects as values.
+func (s *Storage) FetchAll(ctx context.Context) (map[string]goacmedns.Account, error) {
+	pairs, _, err := s.kv.List(s.prefix, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	accounts := make(map[string]goacmedns.Account, len(pairs))
+
+	for _, pair := range pairs {
+		domain := strings.TrimPrefix(pair.Key, s.prefix)
+
+		var acct goacmedns.Account
+
+		err = json.Unmarshal(pair.Value, &acct)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal account for %q: %w", domain, err)
+		}
+
+		accounts[domain] = acct
+	}
+
+	return accounts, nil
+}
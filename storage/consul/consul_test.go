@@ -0,0 +1,169 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+var testAccounts = map[string]goacmedns.Account{
+	"lettuceencrypt.org": {
+		FullDomain: "lettuceencrypt.org",
+		SubDomain:  "tossed.lettuceencrypt.org",
+		Username:   "cpu",
+		Password:   "hunter2",
+		ServerURL:  "https://auth.acme-dns.io",
+		AllowFrom:  []string{"192.168.100.1/24"},
+	},
+	"threeletter.agency": {
+		FullDomain: "threeletter.agency",
+		SubDomain:  "jobs.threeletter.agency",
+		Username:   "spooky.mulder",
+		Password:   "trustno1",
+		ServerURL:  "https://example.org",
+	},
+}
+
+// fakeKV is an in-memory implementation of [KV].
+type fakeKV struct {
+	pairs map[string][]byte
+	err   error
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{pairs: make(map[string][]byte)}
+}
+
+func (f *fakeKV) Get(key string, _ *api.QueryOptions) (*api.KVPair, *api.QueryMeta, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+
+	value, ok := f.pairs[key]
+	if !ok {
+		return nil, &api.QueryMeta{}, nil
+	}
+
+	return &api.KVPair{Key: key, Value: value}, &api.QueryMeta{}, nil
+}
+
+func (f *fakeKV) Put(p *api.KVPair, _ *api.WriteOptions) (*api.WriteMeta, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	f.pairs[p.Key] = p.Value
+
+	return &api.WriteMeta{}, nil
+}
+
+func (f *fakeKV) List(prefix string, _ *api.QueryOptions) (api.KVPairs, *api.QueryMeta, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+
+	var pairs api.KVPairs
+
+	for key, value := range f.pairs {
+		if strings.HasPrefix(key, prefix) {
+			pairs = append(pairs, &api.KVPair{Key: key, Value: value})
+		}
+	}
+
+	return pairs, &api.QueryMeta{}, nil
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+
+	kv := newFakeKV()
+	kv.pairs["other/example.com"] = []byte(`{}`)
+
+	st := New(kv, "goacmedns/accounts/")
+
+	for domain, acct := range testAccounts {
+		err := st.Put(ctx, domain, acct)
+		if err != nil {
+			t.Fatalf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	if _, found := kv.pairs["goacmedns/accounts/lettuceencrypt.org"]; !found {
+		t.Errorf("expected the account to be stored under the prefix, got keys %v", kv.pairs)
+	}
+
+	for domain, expected := range testAccounts {
+		acct, err := st.Fetch(ctx, domain)
+		if err != nil {
+			t.Fatalf("unexpected error fetching domain %q from storage: %v", domain, err)
+		}
+
+		if !reflect.DeepEqual(acct, expected) {
+			t.Errorf("expected domain %q to have account %#v, had %#v", domain, expected, acct)
+		}
+	}
+
+	accounts, err := st.FetchAll(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error fetching all accounts: %v", err)
+	}
+
+	if !reflect.DeepEqual(accounts, testAccounts) {
+		t.Errorf("expected accounts %#v, got %#v", testAccounts, accounts)
+	}
+}
+
+func TestStorage_Fetch_notFound(t *testing.T) {
+	st := New(newFakeKV(), "goacmedns")
+
+	_, err := st.Fetch(context.Background(), "doesnotexist.example.com")
+	if !errors.Is(err, storage.ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound, got %v", err)
+	}
+}
+
+func TestStorage_Put_invalidAccount(t *testing.T) {
+	kv := newFakeKV()
+	st := New(kv, "goacmedns")
+
+	err := st.Put(context.Background(), "example.com", goacmedns.Account{FullDomain: "example.com"})
+	if !errors.Is(err, goacmedns.ErrInvalidAccount) {
+		t.Errorf("expected ErrInvalidAccount, got %v", err)
+	}
+
+	if len(kv.pairs) != 0 {
+		t.Errorf("expected no key to be written, got %v", kv.pairs)
+	}
+}
+
+func TestStorage_kvError(t *testing.T) {
+	ctx := context.Background()
+
+	errKV := errors.New("consul unavailable")
+
+	kv := newFakeKV()
+	kv.err = errKV
+
+	st := New(kv, "goacmedns")
+
+	err := st.Put(ctx, "lettuceencrypt.org", testAccounts["lettuceencrypt.org"])
+	if !errors.Is(err, errKV) {
+		t.Errorf("expected error %v from Put, got %v", errKV, err)
+	}
+
+	_, err = st.Fetch(ctx, "lettuceencrypt.org")
+	if !errors.Is(err, errKV) {
+		t.Errorf("expected error %v from Fetch, got %v", errKV, err)
+	}
+
+	_, err = st.FetchAll(ctx)
+	if !errors.Is(err, errKV) {
+		t.Errorf("expected error %v from FetchAll, got %v", errKV, err)
+	}
+}
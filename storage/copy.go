@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// CopyStorage copies the accounts of the `src` storage into the `dst` storage, e.g. to migrate to another backend,
+// then saves the `dst` storage. It returns the number of accounts put into `dst`.
+// The domains already present in `dst` are skipped, unless `overwrite` is true:
+// their account is then replaced by the one of `src` when they differ.
+// Copying again the same storages copies nothing.
+// The labeled accounts of a [File] are copied too (see [File.PutLabeled]).
+func CopyStorage(ctx context.Context, dst, src goacmedns.Storage, overwrite bool) (int, error) {
+	accounts, err := fetchAllKeys(ctx, src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch the source accounts: %w", err)
+	}
+
+	return putAll(ctx, dst, accounts, overwrite)
+}
+
+// putAll puts the accounts into the `dst` storage, then saves it, and returns the number of accounts put into `dst`.
+// The domains already present in `dst` are skipped, unless `overwrite` is true (see [CopyStorage]).
+func putAll(ctx context.Context, dst goacmedns.Storage, accounts map[string]goacmedns.Account, overwrite bool) (int, error) {
+	existing, err := fetchAllKeys(ctx, dst)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch the destination accounts: %w", err)
+	}
+
+	var count int
+
+	for _, domain := range slices.Sorted(maps.Keys(accounts)) {
+		acct := accounts[domain]
+
+		if current, found := existing[domain]; found && (!overwrite || current.Equal(acct)) {
+			continue
+		}
+
+		err = dst.Put(ctx, domain, acct)
+		if err != nil {
+			return count, fmt.Errorf("failed to put the account for %q: %w", domain, err)
+		}
+
+		count++
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+
+	err = dst.Save(ctx)
+	if err != nil {
+		return count, fmt.Errorf("failed to save the destination storage: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+func TestCopyStorage(t *testing.T) {
+	changed := testAccounts["threeletter.agency"]
+	changed.Password = "rotated"
+
+	testCases := []struct {
+		Name             string
+		Existing         map[string]goacmedns.Account
+		Overwrite        bool
+		ExpectedCount    int
+		ExpectedAccounts map[string]goacmedns.Account
+	}{
+		{
+			Name:             "empty destination",
+			ExpectedCount:    2,
+			ExpectedAccounts: testAccounts,
+		},
+		{
+			Name:          "skip existing",
+			Existing:      map[string]goacmedns.Account{"threeletter.agency": changed},
+			ExpectedCount: 1,
+			ExpectedAccounts: map[string]goacmedns.Account{
+				"lettuceencrypt.org": testAccounts["lettuceencrypt.org"],
+				"threeletter.agency": changed,
+			},
+		},
+		{
+			Name:             "overwrite existing",
+			Existing:         map[string]goacmedns.Account{"threeletter.agency": changed},
+			Overwrite:        true,
+			ExpectedCount:    2,
+			ExpectedAccounts: testAccounts,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+
+			fsys := newMemFS()
+
+			src := NewFile(filepath.Join("testdata", "accounts.json"), 0o600)
+			dst := NewFile("dst.json", 0o600, WithFilesystem(fsys))
+
+			for domain, acct := range tc.Existing {
+				err := dst.Put(ctx, domain, acct)
+				if err != nil {
+					t.Fatalf("unexpected error adding account to storage: %v", err)
+				}
+			}
+
+			count, err := CopyStorage(ctx, dst, src, tc.Overwrite)
+			if err != nil {
+				t.Fatalf("unexpected error copying storage: %v", err)
+			}
+
+			if count != tc.ExpectedCount {
+				t.Errorf("expected %d copied accounts, got %d", tc.ExpectedCount, count)
+			}
+
+			// The destination is saved.
+			restored := NewFile("dst.json", 0o600, WithFilesystem(fsys))
+
+			if !reflect.DeepEqual(restored.accounts, tc.ExpectedAccounts) {
+				t.Errorf("expected accounts %#v, got %#v", tc.ExpectedAccounts, restored.accounts)
+			}
+
+			// Copying again is a no-op.
+			count, err = CopyStorage(ctx, dst, src, tc.Overwrite)
+			if err != nil {
+				t.Fatalf("unexpected error copying storage: %v", err)
+			}
+
+			if count != 0 {
+				t.Errorf("expected no copied account on the second copy, got %d", count)
+			}
+		})
+	}
+}
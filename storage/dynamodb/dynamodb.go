@@ -0,0 +1,193 @@
+// Package dynamodb provides a [goacmedns.Storage] implementation backed by an Amazon DynamoDB table.
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+// Attribute names of the items.
+const (
+	attrDomain     = "domain"
+	attrFullDomain = "fulldomain"
+	attrSubDomain  = "subdomain"
+	attrUsername   = "username"
+	attrPassword   = "password"
+	attrServerURL  = "server_url"
+	attrAllowFrom  = "allowfrom"
+	attrCreatedAt  = "created_at"
+	attrLastUsedAt = "last_used_at"
+)
+
+var _ goacmedns.Storage = (*Storage)(nil)
+
+// API is the subset of the DynamoDB client used by [Storage].
+// It is implemented by [awsdynamodb.Client].
+type API interface {
+	GetItem(ctx context.Context, params *awsdynamodb.GetItemInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.GetItemOutput, error)
+	PutItem(ctx context.Context, params *awsdynamodb.PutItemInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.PutItemOutput, error)
+	Scan(ctx context.Context, params *awsdynamodb.ScanInput, optFns ...func(*awsdynamodb.Options)) (*awsdynamodb.ScanOutput, error)
+}
+
+// Storage implements the [goacmedns.Storage] interface and persists the accounts in a DynamoDB table.
+// Each account is stored as an item keyed by domain, with an attribute for each [goacmedns.Account] field.
+// The table must have a string partition key named `domain`.
+type Storage struct {
+	client API
+	table  string
+}
+
+// New returns a [Storage] persisting the accounts into the given DynamoDB table.
+func New(client API, table string) *Storage {
+	return &Storage{client: client, table: table}
+}
+
+// Save is a no-op: the accounts are written to the table by [Storage.Put].
+func (s *Storage) Save(_ context.Context) error {
+	return nil
+}
+
+// Put writes the [goacmedns.Account] for the given `domain` to the table.
+// The [goacmedns.Account] is checked with [goacmedns.Account.Validate] before being written.
+func (s *Storage) Put(ctx context.Context, domain string, acct goacmedns.Account) error {
+	err := acct.Validate()
+	if err != nil {
+		return fmt.Errorf("account for %q: %w", domain, err)
+	}
+
+	_, err = s.client.PutItem(ctx, &awsdynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      marshalItem(domain, acct),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put account for %q: %w", domain, err)
+	}
+
+	return nil
+}
+
+// Fetch retrieves the [goacmedns.Account] for the given `domain` from the table.
+// If the `domain` provided does not have a [goacmedns.Account] in the table an [storage.ErrDomainNotFound] error is returned.
+func (s *Storage) Fetch(ctx context.Context, domain string) (goacmedns.Account, error) {
+	out, err := s.client.GetItem(ctx, &awsdynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]types.AttributeValue{
+			attrDomain: &types.AttributeValueMemberS{Value: domain},
+		},
+	})
+	if err != nil {
+		return goacmedns.Account{}, fmt.Errorf("failed to get account for %q: %w", domain, err)
+	}
+
+	if len(out.Item) == 0 {
+		return goacmedns.Account{}, storage.ErrDomainNotFound
+	}
+
+	_, acct := unmarshalItem(out.Item)
+
+	return acct, nil
+}
+
+// FetchAll scans the table and returns a map that has domain names as its keys and [goacmedns.Account] objects as values.
+func (s *Storage) FetchAll(ctx context.Context) (map[string]goacmedns.Account, error) {
+	accounts := make(map[string]goacmedns.Account)
+
+	input := &awsdynamodb.ScanInput{TableName: aws.String(s.table)}
+
+	for {
+		out, err := s.client.Scan(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan accounts: %w", err)
+		}
+
+		for _, item := range out.Items {
+			domain, acct := unmarshalItem(item)
+			accounts[domain] = acct
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return accounts, nil
+		}
+
+		input.ExclusiveStartKey = maps.Clone(out.LastEvaluatedKey)
+	}
+}
+
+func marshalItem(domain string, acct goacmedns.Account) map[string]types.AttributeValue {
+	item := map[string]types.AttributeValue{
+		attrDomain:     &types.AttributeValueMemberS{Value: domain},
+		attrFullDomain: &types.AttributeValueMemberS{Value: acct.FullDomain},
+		attrSubDomain:  &types.AttributeValueMemberS{Value: acct.SubDomain},
+		attrUsername:   &types.AttributeValueMemberS{Value: acct.Username},
+		attrPassword:   &types.AttributeValueMemberS{Value: acct.Password},
+		attrServerURL:  &types.AttributeValueMemberS{Value: acct.ServerURL},
+	}
+
+	if len(acct.AllowFrom) > 0 {
+		allowFrom := make([]types.AttributeValue, 0, len(acct.AllowFrom))
+		for _, cidr := range acct.AllowFrom {
+			allowFrom = append(allowFrom, &types.AttributeValueMemberS{Value: cidr})
+		}
+
+		item[attrAllowFrom] = &types.AttributeValueMemberL{Value: allowFrom}
+	}
+
+	if !acct.CreatedAt.IsZero() {
+		item[attrCreatedAt] = &types.AttributeValueMemberS{Value: acct.CreatedAt.Format(time.RFC3339Nano)}
+	}
+
+	if !acct.LastUsedAt.IsZero() {
+		item[attrLastUsedAt] = &types.AttributeValueMemberS{Value: acct.LastUsedAt.Format(time.RFC3339Nano)}
+	}
+
+	return item
+}
+
+func unmarshalItem(item map[string]types.AttributeValue) (string, goacmedns.Account) {
+	acct := goacmedns.Account{
+		FullDomain: stringAttr(item, attrFullDomain),
+		SubDomain:  stringAttr(item, attrSubDomain),
+		Username:   stringAttr(item, attrUsername),
+		Password:   stringAttr(item, attrPassword),
+		ServerURL:  stringAttr(item, attrServerURL),
+	}
+
+	if list, ok := item[attrAllowFrom].(*types.AttributeValueMemberL); ok {
+		for _, v := range list.Value {
+			if s, ok := v.(*types.AttributeValueMemberS); ok {
+				acct.AllowFrom = append(acct.AllowFrom, s.Value)
+			}
+		}
+	}
+
+	acct.CreatedAt = timeAttr(item, attrCreatedAt)
+	acct.LastUsedAt = timeAttr(item, attrLastUsedAt)
+
+	return stringAttr(item, attrDomain), acct
+}
+
+func stringAttr(item map[string]types.AttributeValue, name string) string {
+	if v, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return v.Value
+	}
+
+	return ""
+}
+
+// timeAttr returns the time of an RFC 3339 string attribute, or the zero time if it's missing or malformed.
+func timeAttr(item map[string]types.AttributeValue, name string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, stringAttr(item, name))
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
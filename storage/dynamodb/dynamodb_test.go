@@ -0,0 +1,173 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	awsdynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+var testAccounts = map[string]goacmedns.Account{
+	"lettuceencrypt.org": {
+		FullDomain: "lettuceencrypt.org",
+		SubDomain:  "tossed.lettuceencrypt.org",
+		Username:   "cpu",
+		Password:   "hunter2",
+		ServerURL:  "https://auth.acme-dns.io",
+		AllowFrom:  []string{"192.168.100.1/24"},
+	},
+	"threeletter.agency": {
+		FullDomain: "threeletter.agency",
+		SubDomain:  "jobs.threeletter.agency",
+		Username:   "spooky.mulder",
+		Password:   "trustno1",
+		ServerURL:  "https://example.org",
+		CreatedAt:  time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC),
+		LastUsedAt: time.Date(2025, time.March, 1, 8, 30, 0, 0, time.UTC),
+	},
+}
+
+// fakeAPI is an in-memory implementation of [API].
+// Scan returns one item per page to exercise the pagination.
+type fakeAPI struct {
+	table string
+	items map[string]map[string]types.AttributeValue
+}
+
+func newFakeAPI(table string) *fakeAPI {
+	return &fakeAPI{table: table, items: make(map[string]map[string]types.AttributeValue)}
+}
+
+func (f *fakeAPI) GetItem(_ context.Context, params *awsdynamodb.GetItemInput, _ ...func(*awsdynamodb.Options)) (*awsdynamodb.GetItemOutput, error) {
+	if *params.TableName != f.table {
+		return nil, errors.New("unknown table")
+	}
+
+	return &awsdynamodb.GetItemOutput{Item: f.items[stringAttr(params.Key, attrDomain)]}, nil
+}
+
+func (f *fakeAPI) PutItem(_ context.Context, params *awsdynamodb.PutItemInput, _ ...func(*awsdynamodb.Options)) (*awsdynamodb.PutItemOutput, error) {
+	if *params.TableName != f.table {
+		return nil, errors.New("unknown table")
+	}
+
+	f.items[stringAttr(params.Item, attrDomain)] = params.Item
+
+	return &awsdynamodb.PutItemOutput{}, nil
+}
+
+func (f *fakeAPI) Scan(_ context.Context, params *awsdynamodb.ScanInput, _ ...func(*awsdynamodb.Options)) (*awsdynamodb.ScanOutput, error) {
+	if *params.TableName != f.table {
+		return nil, errors.New("unknown table")
+	}
+
+	keys := make([]string, 0, len(f.items))
+	for k := range f.items {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	start := 0
+	if params.ExclusiveStartKey != nil {
+		start = slices.Index(keys, stringAttr(params.ExclusiveStartKey, attrDomain)) + 1
+	}
+
+	out := &awsdynamodb.ScanOutput{}
+
+	if start < len(keys) {
+		out.Items = append(out.Items, f.items[keys[start]])
+
+		if start < len(keys)-1 {
+			out.LastEvaluatedKey = map[string]types.AttributeValue{
+				attrDomain: &types.AttributeValueMemberS{Value: keys[start]},
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+
+	st := New(newFakeAPI("accounts"), "accounts")
+
+	for domain, acct := range testAccounts {
+		err := st.Put(ctx, domain, acct)
+		if err != nil {
+			t.Fatalf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	for domain, expected := range testAccounts {
+		acct, err := st.Fetch(ctx, domain)
+		if err != nil {
+			t.Fatalf("unexpected error fetching domain %q from storage: %v", domain, err)
+		}
+
+		if !reflect.DeepEqual(acct, expected) {
+			t.Errorf("expected domain %q to have account %#v, had %#v", domain, expected, acct)
+		}
+	}
+
+	accounts, err := st.FetchAll(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error fetching all accounts: %v", err)
+	}
+
+	if !reflect.DeepEqual(accounts, testAccounts) {
+		t.Errorf("expected accounts %#v, got %#v", testAccounts, accounts)
+	}
+}
+
+func TestStorage_Fetch_notFound(t *testing.T) {
+	st := New(newFakeAPI("accounts"), "accounts")
+
+	_, err := st.Fetch(context.Background(), "doesnotexist.example.com")
+	if !errors.Is(err, storage.ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound, got %v", err)
+	}
+}
+
+func TestStorage_Put_invalidAccount(t *testing.T) {
+	api := newFakeAPI("accounts")
+	st := New(api, "accounts")
+
+	err := st.Put(context.Background(), "example.com", goacmedns.Account{FullDomain: "example.com"})
+	if !errors.Is(err, goacmedns.ErrInvalidAccount) {
+		t.Errorf("expected ErrInvalidAccount, got %v", err)
+	}
+
+	if len(api.items) != 0 {
+		t.Errorf("expected no item to be written, got %v", api.items)
+	}
+}
+
+func TestStorage_apiError(t *testing.T) {
+	ctx := context.Background()
+
+	st := New(newFakeAPI("accounts"), "other")
+
+	err := st.Put(ctx, "lettuceencrypt.org", testAccounts["lettuceencrypt.org"])
+	if err == nil {
+		t.Error("expected an error from Put, got nil")
+	}
+
+	_, err = st.Fetch(ctx, "lettuceencrypt.org")
+	if err == nil {
+		t.Error("expected an error from Fetch, got nil")
+	}
+
+	_, err = st.FetchAll(ctx)
+	if err == nil {
+		t.Error("expected an error from FetchAll, got nil")
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// NewFromEnv returns a [File] storage loaded from the JSON content of the environment variable `varName`,
+// e.g. the accounts injected as a secret by a serverless or container platform, without writing them to disk.
+// Unlike [NewFile], an error is returned if the variable is not set or its content is malformed.
+// The accounts are kept in memory: they can be changed, but [File.Save] is a no-op,
+// and [File.Reload] reads the variable again.
+func NewFromEnv(varName string) (*File, error) {
+	f := &File{
+		path:     varName,
+		accounts: make(map[string]goacmedns.Account),
+		codec:    jsonCodec,
+		fsys:     envFilesystem{},
+		inMemory: true,
+	}
+
+	err := f.load(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// envFilesystem is a [Filesystem] reading the files from the environment variables of the same name.
+// The writes are never issued by a [File] created with [NewFromEnv], and are rejected.
+type envFilesystem struct{}
+
+func (envFilesystem) ReadFile(name string) ([]byte, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set: %w", name, fs.ErrNotExist)
+	}
+
+	return []byte(value), nil
+}
+
+func (envFilesystem) WriteFile(name string, _ []byte, _ fs.FileMode) error {
+	return fmt.Errorf("write %s: %w", name, errors.ErrUnsupported)
+}
+
+func (envFilesystem) Rename(oldpath, _ string) error {
+	return fmt.Errorf("rename %s: %w", oldpath, errors.ErrUnsupported)
+}
+
+func (envFilesystem) Remove(name string) error {
+	return fmt.Errorf("remove %s: %w", name, errors.ErrUnsupported)
+}
+
+func (envFilesystem) Stat(name string) (fs.FileInfo, error) {
+	return nil, fmt.Errorf("stat %s: %w", name, errors.ErrUnsupported)
+}
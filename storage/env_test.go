@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestNewFromEnv(t *testing.T) {
+	data, err := os.ReadFile("testdata/accounts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GOACMEDNS_ACCOUNTS", string(data))
+
+	ctx := context.Background()
+
+	st, err := NewFromEnv("GOACMEDNS_ACCOUNTS")
+	if err != nil {
+		t.Fatalf("unexpected error loading storage: %v", err)
+	}
+
+	accounts, err := st.FetchAll(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error fetching accounts: %v", err)
+	}
+
+	if !reflect.DeepEqual(accounts, testAccounts) {
+		t.Errorf("expected accounts %#v, got %#v", testAccounts, accounts)
+	}
+
+	err = st.Put(ctx, "example.com", testAccounts["lettuceencrypt.org"])
+	if err != nil {
+		t.Fatalf("unexpected error putting account: %v", err)
+	}
+
+	err = st.Save(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error saving storage: %v", err)
+	}
+
+	if _, err = st.Fetch(ctx, "example.com"); err != nil {
+		t.Errorf("unexpected error fetching the new account: %v", err)
+	}
+
+	if _, err = os.Stat("GOACMEDNS_ACCOUNTS"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected no file to be written, got %v", err)
+	}
+
+	// Reloading reads the variable again, dropping the unsaved account.
+	err = st.Reload(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error reloading storage: %v", err)
+	}
+
+	if _, err = st.Fetch(ctx, "example.com"); !errors.Is(err, ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound after reload, got %v", err)
+	}
+}
+
+func TestNewFromEnv_errors(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Unset       bool
+		Value       string
+		ExpectedErr error
+	}{
+		{Name: "unset variable", Unset: true, ExpectedErr: fs.ErrNotExist},
+		{Name: "empty variable", Value: ""},
+		{Name: "malformed JSON", Value: `{"lettuceencrypt.org": `},
+		{Name: "unexpected JSON", Value: `["lettuceencrypt.org"]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			if !tc.Unset {
+				t.Setenv("GOACMEDNS_ACCOUNTS", tc.Value)
+			}
+
+			_, err := NewFromEnv("GOACMEDNS_ACCOUNTS")
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+
+			if tc.ExpectedErr != nil && !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
@@ -2,60 +2,206 @@ package storage
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
+	"iter"
+	"maps"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/nrdcg/goacmedns"
 )
 
-var _ goacmedns.Storage = (*File)(nil)
+var (
+	_ goacmedns.Storage  = (*File)(nil)
+	_ goacmedns.Iterable = (*File)(nil)
+)
 
 // ErrDomainNotFound is returned from [File.Fetch] when the provided domain is not present in the storage.
-var ErrDomainNotFound = errors.New("requested domain is not present in storage")
+// It is [goacmedns.ErrDomainNotFound], so that the users of a [goacmedns.Storage] can check it without this package.
+var ErrDomainNotFound = goacmedns.ErrDomainNotFound
+
+// ErrEmptyServerURL is returned from [File.Migrate] when the provided server URL is empty.
+var ErrEmptyServerURL = errors.New("server URL must not be empty")
+
+// ErrUnsupportedVersion is returned when loading a storage file written with a newer format version than supported.
+var ErrUnsupportedVersion = errors.New("unsupported storage file version")
+
+// fileVersion is the version of the storage file format written by [File.Save].
+// Storage files without a version hold the accounts map at the top level (legacy format).
+const fileVersion = 1
+
+// fileContent is the versioned envelope of the accounts in the storage file.
+type fileContent struct {
+	Version  int                          `json:"version"  yaml:"version"`
+	Accounts map[string]goacmedns.Account `json:"accounts" yaml:"accounts"`
+}
 
 // File implements the [goacmedns.Storage] interface and persists `accounts` to a JSON file on disk.
+// It is safe for concurrent use.
 type File struct {
+	// mu guards `accounts`.
+	mu sync.RWMutex
 	// path is the filepath that the `accounts` are persisted to when the [File.Save] function is called.
 	path string
 	// mode is the file mode used when the `path` JSON file must be created.
 	mode os.FileMode
 	// accounts holds the `Account` data that has been [File.Put] into the storage.
 	accounts map[string]goacmedns.Account
+	// codec is used to marshal and unmarshal the `accounts` (JSON by default).
+	codec codec
+	// fsyncFallback enables the in-place write strategy with a checksum sidecar (see [WithFsyncFallback]).
+	fsyncFallback bool
+	// fsys is the filesystem the `path` is read from and written to (the OS filesystem by default).
+	fsys Filesystem
+	// autoSave enables saving the `accounts` on every change (see [WithAutoSave]).
+	autoSave bool
+	// fileLock enables the advisory locking of the `path` across processes (see [WithFileLock]).
+	fileLock bool
+	// readOnly rejects the changes and the saves with [ErrReadOnly] (see [NewReadOnlyFS]).
+	readOnly bool
+	// inMemory makes [File.Save] a no-op, as there's no file to persist the `accounts` to (see [NewFromEnv]).
+	inMemory bool
+}
+
+// FileOption configures a [File] storage.
+type FileOption func(f *File)
+
+// WithFsyncFallback replaces the atomic rename used by [File.Save] with an in-place write and fsync,
+// for filesystems where renaming over an existing file isn't atomic (e.g. some network filesystems).
+// A copy of the data is first written to a backup file, and each file is followed by a SHA-256 checksum sidecar file.
+// When loading, if the main file doesn't match its checksum, the data is recovered from the backup file.
+func WithFsyncFallback() FileOption {
+	return func(f *File) {
+		f.fsyncFallback = true
+	}
+}
+
+// WithAutoSave makes [File.Put] and [File.Delete] persist the accounts to disk immediately,
+// as if [File.Save] was called after each of them.
+// This trades performance for safety against forgotten [File.Save] calls.
+func WithAutoSave() FileOption {
+	return func(f *File) {
+		f.autoSave = true
+	}
 }
 
 // NewFile returns a [goacmedns.Storage] implementation backed by JSON content saved into the provided `path` on disk.
 // The file at `path` will be created if required.
 // When creating a new file, the provided `mode` is used to set the permissions.
-func NewFile(path string, mode os.FileMode) *File {
+// The storage is empty if the file can't be loaded, e.g. when it's malformed:
+// use [OpenFile] to avoid overwriting such a file on the next save.
+func NewFile(path string, mode os.FileMode, opts ...FileOption) *File {
+	f := newFile(path, mode, opts...)
+
+	// Opportunistically, try to load the account data. Return an empty account if any errors occur.
+	_ = f.initialLoad()
+
+	return f
+}
+
+// OpenFile returns a [goacmedns.Storage] implementation like [NewFile],
+// but returns an error if the file at `path` exists and can't be loaded,
+// e.g. when it's malformed, or written with a newer format version (see [ErrUnsupportedVersion]).
+// A missing file isn't an error, it will be created on the first save.
+func OpenFile(path string, mode os.FileMode, opts ...FileOption) (*File, error) {
+	f := newFile(path, mode, opts...)
+
+	err := f.initialLoad()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// newFile returns an empty [File] storage for the `path`, configured with the options.
+func newFile(path string, mode os.FileMode, opts ...FileOption) *File {
 	f := &File{
 		path:     path,
 		mode:     mode,
 		accounts: make(map[string]goacmedns.Account),
+		codec:    jsonCodec,
+		fsys:     osFilesystem{},
 	}
 
-	// Opportunistically, try to load the account data. Return an empty account if any errors occur.
-	jsonData, err := os.ReadFile(path)
-	if err == nil {
-		err = json.Unmarshal(jsonData, &f.accounts)
-		if err != nil {
-			return f
-		}
+	for _, opt := range opts {
+		opt(f)
 	}
 
 	return f
 }
 
+// initialLoad loads the account data when creating the storage.
+func (f *File) initialLoad() error {
+	// Bounds the wait for a lock held by another process (see [WithFileLock]).
+	ctx, cancel := context.WithTimeout(context.Background(), loadLockTimeout)
+	defer cancel()
+
+	return f.load(ctx)
+}
+
+// NewYAMLFile returns a [goacmedns.Storage] implementation like [NewFile], but backed by YAML content instead of JSON.
+func NewYAMLFile(path string, mode os.FileMode, opts ...FileOption) *File {
+	return NewFile(path, mode, append([]FileOption{withCodec(yamlCodec)}, opts...)...)
+}
+
 // Save persists the [goacmedns.Account] data to the file's configured `path`.
+// The data is written to a temporary file which is then renamed to `path`,
+// so that an interrupted save never leaves a partially written storage file.
+// When the context is done before the rename, the storage file is left unchanged,
+// the temporary file is removed, and the context error is returned.
+// With [WithFsyncFallback], the file is instead written in place after a backup copy, with checksum sidecar files,
+// and the context is only checked before the first write.
 // The file at that path will be created with the file's `mode` if required.
-func (f File) Save(_ context.Context) error {
-	serialized, err := json.Marshal(f.accounts)
+// For a [File] created with [NewFromEnv], it does nothing.
+func (f *File) Save(ctx context.Context) error {
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	if f.inMemory {
+		return nil
+	}
+
+	// The write lock serializes the saves, which share the temporary file.
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.save(ctx)
+}
+
+// save persists the accounts to disk.
+// The caller must hold the lock.
+func (f *File) save(ctx context.Context) (err error) {
+	if f.fileLock {
+		release, errLock := lockFile(ctx, f.path, true)
+		if errLock != nil {
+			return errLock
+		}
+
+		defer func() { err = errors.Join(err, release()) }()
+	}
+
+	return f.write(ctx, f.accounts)
+}
+
+// write persists the accounts to disk, without locking the storage file.
+func (f *File) write(ctx context.Context, accounts map[string]goacmedns.Account) error {
+	serialized, err := f.codec.marshal(fileContent{Version: fileVersion, Accounts: accounts})
 	if err != nil {
-		return fmt.Errorf("fFailed to marshal account: %w", err)
+		return fmt.Errorf("failed to marshal account: %w", err)
+	}
+
+	if f.fsyncFallback {
+		err = writeWithChecksum(ctx, f.fsys, f.path, serialized, f.mode)
+	} else {
+		err = writeAtomic(ctx, f.fsys, f.path, serialized, f.mode)
 	}
 
-	err = os.WriteFile(f.path, serialized, f.mode)
 	if err != nil {
 		return fmt.Errorf("failed to write storage file: %w", err)
 	}
@@ -64,16 +210,130 @@ func (f File) Save(_ context.Context) error {
 }
 
 // Put saves a [goacmedns.Account] for the given `domain` into the in-memory accounts of the file instance.
-// The [goacmedns.Account] data will not be written to disk until the [File.Save] function is called.
-func (f File) Put(_ context.Context, domain string, acct goacmedns.Account) error {
+// The [goacmedns.Account] is checked with [goacmedns.Account.Validate] before being saved.
+// The [goacmedns.Account] data will not be written to disk until the [File.Save] function is called,
+// unless the file was created with [WithAutoSave].
+func (f *File) Put(ctx context.Context, domain string, acct goacmedns.Account) error {
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	err := acct.Validate()
+	if err != nil {
+		return fmt.Errorf("account for %q: %w", domain, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	f.accounts[domain] = acct
 
+	return f.autoSaveIfEnabled(ctx)
+}
+
+// Delete removes the [goacmedns.Account] for the given `domain` from the in-memory accounts of the file instance.
+// If the `domain` provided does not have a [goacmedns.Account] in the storage an [ErrDomainNotFound] error is returned.
+// The removal will not be written to disk until the [File.Save] function is called,
+// unless the file was created with [WithAutoSave].
+func (f *File) Delete(ctx context.Context, domain string) error {
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.accounts[domain]; !exists {
+		return ErrDomainNotFound
+	}
+
+	delete(f.accounts, domain)
+
+	return f.autoSaveIfEnabled(ctx)
+}
+
+// autoSaveIfEnabled persists the accounts to disk when the file was created with [WithAutoSave].
+// The caller must hold the lock.
+func (f *File) autoSaveIfEnabled(ctx context.Context) error {
+	if !f.autoSave {
+		return nil
+	}
+
+	err := f.save(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to auto-save: %w", err)
+	}
+
 	return nil
 }
 
+// Migrate sets the `ServerURL` of the legacy accounts (see [LegacyDomains]) to the given `defaultServerURL`,
+// and returns the number of updated accounts.
+// The changes will not be written to disk until the [File.Save] function is called,
+// unless the file was created with [WithAutoSave].
+func (f *File) Migrate(ctx context.Context, defaultServerURL string) (int, error) {
+	if f.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	if defaultServerURL == "" {
+		return 0, ErrEmptyServerURL
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	domains := LegacyDomains(f.accounts)
+	if len(domains) == 0 {
+		return 0, nil
+	}
+
+	for _, domain := range domains {
+		acct := f.accounts[domain]
+		acct.ServerURL = defaultServerURL
+		f.accounts[domain] = acct
+	}
+
+	return len(domains), f.autoSaveIfEnabled(ctx)
+}
+
+// Prune removes all the accounts matching the given `predicate`, and returns the number of removed accounts.
+// A nil `predicate` removes all the accounts.
+// The removals will not be written to disk until the [File.Save] function is called,
+// unless the file was created with [WithAutoSave].
+func (f *File) Prune(ctx context.Context, predicate func(domain string, acct goacmedns.Account) bool) (int, error) {
+	if f.readOnly {
+		return 0, ErrReadOnly
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var count int
+
+	for domain, acct := range f.accounts {
+		if predicate != nil && !predicate(domain, acct) {
+			continue
+		}
+
+		delete(f.accounts, domain)
+
+		count++
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+
+	return count, f.autoSaveIfEnabled(ctx)
+}
+
 // Fetch retrieves the [goacmedns.Account] object for the given `domain` from the file in-memory accounts.
 // If the `domain` provided does not have a [goacmedns.Account] in the storage an [ErrDomainNotFound] error is returned.
-func (f File) Fetch(_ context.Context, domain string) (goacmedns.Account, error) {
+func (f *File) Fetch(_ context.Context, domain string) (goacmedns.Account, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
 	if acct, exists := f.accounts[domain]; exists {
 		return acct, nil
 	}
@@ -81,8 +341,157 @@ func (f File) Fetch(_ context.Context, domain string) (goacmedns.Account, error)
 	return goacmedns.Account{}, ErrDomainNotFound
 }
 
+// FindByFullDomain retrieves the domain and the [goacmedns.Account] whose `FullDomain` is the given `fullDomain`,
+// e.g. to find the account matching a subdomain seen in the ACME-DNS server logs.
+// The comparison is case-insensitive and ignores a trailing dot.
+// If no [goacmedns.Account] in the storage matches the `fullDomain` an [ErrDomainNotFound] error is returned.
+func (f *File) FindByFullDomain(_ context.Context, fullDomain string) (string, goacmedns.Account, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	fullDomain = strings.TrimSuffix(fullDomain, ".")
+
+	for domain, acct := range f.accounts {
+		if strings.EqualFold(strings.TrimSuffix(acct.FullDomain, "."), fullDomain) {
+			return domain, acct, nil
+		}
+	}
+
+	return "", goacmedns.Account{}, ErrDomainNotFound
+}
+
+// Reload re-reads the account data from the file's configured `path`, replacing the in-memory accounts.
+// It allows picking up changes made to the file by another process.
+// Unlike [NewFile], an error is returned if the file is missing or malformed, and the in-memory accounts are kept unchanged.
+func (f *File) Reload(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.load(ctx)
+}
+
+// load reads and unmarshals the account data from the file's configured `path`.
+// The caller must hold the write lock, or have exclusive access to the file instance.
+func (f *File) load(ctx context.Context) (err error) {
+	if f.fileLock {
+		release, errLock := lockFile(ctx, f.path, false)
+		if errLock != nil {
+			return errLock
+		}
+
+		defer func() { err = errors.Join(err, release()) }()
+	}
+
+	accounts, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	f.accounts = accounts
+
+	return nil
+}
+
+// read reads and unmarshals the account data from the file's configured `path`, without locking the storage file.
+func (f *File) read() (map[string]goacmedns.Account, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if f.fsyncFallback {
+		data, err = readWithChecksum(f.fsys, f.path)
+	} else {
+		data, err = f.fsys.ReadFile(f.path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage file: %w", err)
+	}
+
+	accounts, err := f.decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if accounts == nil {
+		// The file contains a null value.
+		accounts = make(map[string]goacmedns.Account)
+	}
+
+	return accounts, nil
+}
+
+// decode unmarshals the accounts of a storage file, either in the versioned or in the legacy format.
+func (f *File) decode(data []byte) (map[string]goacmedns.Account, error) {
+	return decodeAccounts(f.codec, data)
+}
+
+// decodeAccounts unmarshals the accounts of a storage file with the codec,
+// either in the versioned or in the legacy format.
+func decodeAccounts(c codec, data []byte) (map[string]goacmedns.Account, error) {
+	var content fileContent
+
+	// A legacy file may not match the envelope (e.g. with a domain named "version"), it's decoded below.
+	err := c.unmarshal(data, &content)
+	if err == nil && content.Version > fileVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, content.Version)
+	}
+
+	if err == nil && content.Version > 0 {
+		return content.Accounts, nil
+	}
+
+	accounts := make(map[string]goacmedns.Account)
+
+	err = c.unmarshal(data, &accounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal storage file: %w", err)
+	}
+
+	return accounts, nil
+}
+
 // FetchAll retrieves all the [goacmedns.Account] objects from the File and
 // returns a map that has domain names as its keys and [goacmedns.Account] objects as values.
-func (f File) FetchAll(_ context.Context) (map[string]goacmedns.Account, error) {
-	return f.accounts, nil
+// The returned map is a copy and can be modified by the caller.
+// The accounts with a non-default label (see [File.PutLabeled]) are not included, use [File.FetchAllLabeled].
+func (f *File) FetchAll(_ context.Context) (map[string]goacmedns.Account, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	accounts := maps.Clone(f.accounts)
+	maps.DeleteFunc(accounts, func(key string, _ goacmedns.Account) bool { return isLabeledKey(key) })
+
+	return accounts, nil
+}
+
+// All returns an iterator over the domains and their [goacmedns.Account] in the File, sorted by domain.
+// The accounts can be modified while iterating: the accounts removed before being reached are skipped,
+// and the accounts added while iterating are not yielded.
+// Like with [File.FetchAll], the accounts with a non-default label are not included.
+func (f *File) All(_ context.Context) iter.Seq2[string, goacmedns.Account] {
+	return func(yield func(string, goacmedns.Account) bool) {
+		f.mu.RLock()
+		domains := slices.Sorted(maps.Keys(f.accounts))
+		f.mu.RUnlock()
+
+		for _, domain := range domains {
+			if isLabeledKey(domain) {
+				continue
+			}
+
+			f.mu.RLock()
+			acct, exists := f.accounts[domain]
+			f.mu.RUnlock()
+
+			if !exists {
+				continue
+			}
+
+			if !yield(domain, acct) {
+				return
+			}
+		}
+	}
 }
@@ -1,15 +1,22 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
+	"sync"
 	"testing"
 
 	"github.com/nrdcg/goacmedns"
+	"gopkg.in/yaml.v3"
 )
 
 var testAccounts = map[string]goacmedns.Account{
@@ -60,6 +67,157 @@ func TestNewFile_withAccounts(t *testing.T) {
 	}
 }
 
+func TestOpenFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "acmedns.json")
+
+	st, err := OpenFile(file, 0o600)
+	if err != nil {
+		t.Fatalf("expected a missing file to be an empty storage, got %v", err)
+	}
+
+	if len(st.accounts) != 0 {
+		t.Errorf("expected no accounts, got %#v", st.accounts)
+	}
+
+	data, err := os.ReadFile("testdata/accounts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(file, data, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err = OpenFile(file, 0o600)
+	if err != nil {
+		t.Fatalf("unexpected error opening storage: %v", err)
+	}
+
+	if !reflect.DeepEqual(st.accounts, testAccounts) {
+		t.Errorf("expected to have accounts %#v loaded, had %#v", testAccounts, st.accounts)
+	}
+}
+
+func TestOpenFile_errors(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Content     string
+		ExpectedErr error
+	}{
+		{Name: "malformed file", Content: `{`},
+		{Name: "unsupported version", Content: `{"version":2,"accounts":{}}`, ExpectedErr: ErrUnsupportedVersion},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "acmedns.json")
+
+			err := os.WriteFile(file, []byte(tc.Content), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = OpenFile(file, 0o600)
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+
+			if tc.ExpectedErr != nil && !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	data, err := EncodeJSON(testAccounts)
+	if err != nil {
+		t.Fatalf("unexpected error encoding accounts: %v", err)
+	}
+
+	accounts, err := DecodeJSON(data)
+	if err != nil {
+		t.Fatalf("unexpected error decoding accounts: %v", err)
+	}
+
+	if !reflect.DeepEqual(accounts, testAccounts) {
+		t.Errorf("expected accounts %#v, got %#v", testAccounts, accounts)
+	}
+
+	_, err = DecodeJSON([]byte(`{"version":2,"accounts":{}}`))
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+}
+
+func TestFile_load_versions(t *testing.T) {
+	versionDomain := goacmedns.Account{
+		FullDomain: "version",
+		SubDomain:  "sub.version",
+		Username:   "user",
+		Password:   "secret",
+	}
+
+	testCases := []struct {
+		Name             string
+		Content          string
+		ExpectedAccounts map[string]goacmedns.Account
+		ExpectedErr      error
+	}{
+		{
+			Name:             "versioned",
+			Content:          `{"version":1,"accounts":{"version":{"fulldomain":"version","subdomain":"sub.version","username":"user","password":"secret"}}}`,
+			ExpectedAccounts: map[string]goacmedns.Account{"version": versionDomain},
+		},
+		{
+			Name:             "legacy",
+			Content:          `{"version":{"fulldomain":"version","subdomain":"sub.version","username":"user","password":"secret"}}`,
+			ExpectedAccounts: map[string]goacmedns.Account{"version": versionDomain},
+		},
+		{
+			Name:             "versioned without accounts",
+			Content:          `{"version":1}`,
+			ExpectedAccounts: map[string]goacmedns.Account{},
+		},
+		{
+			Name:        "unsupported version",
+			Content:     `{"version":2,"accounts":{}}`,
+			ExpectedErr: ErrUnsupportedVersion,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "acmedns.json")
+
+			err := os.WriteFile(file, []byte(tc.Content), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fs := NewFile(file, 0o600)
+
+			err = fs.Reload(context.Background())
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			if tc.ExpectedErr == nil && !reflect.DeepEqual(fs.accounts, tc.ExpectedAccounts) {
+				t.Errorf("expected to have accounts %#v loaded, had %#v", tc.ExpectedAccounts, fs.accounts)
+			}
+		})
+	}
+}
+
+func TestNewFile_withVersionedAccounts(t *testing.T) {
+	fs := NewFile(filepath.Join("testdata", "accounts_v1.json"), 0o600)
+
+	if !reflect.DeepEqual(fs.accounts, testAccounts) {
+		t.Errorf("expected to have accounts %#v loaded, had %#v", testAccounts, fs.accounts)
+	}
+}
+
 func TestNewFile_withLegacyData(t *testing.T) {
 	fs := NewFile(filepath.Join("testdata", "legacy_account.json"), 0o600)
 
@@ -120,16 +278,20 @@ func TestFile_Save(t *testing.T) {
 		t.Fatalf("unexpected error reading stored JSON from %q: %v", file, err)
 	}
 
-	var restoredData map[string]goacmedns.Account
+	var restoredData fileContent
 
 	err = json.Unmarshal(storedJSON, &restoredData)
 	if err != nil {
 		t.Fatalf("unexpected error unmarshaling stored JSON from %q: %v", file, err)
 	}
 
-	if !reflect.DeepEqual(restoredData, testAccounts) {
+	if restoredData.Version != fileVersion {
+		t.Errorf("expected stored version %d, got %d", fileVersion, restoredData.Version)
+	}
+
+	if !reflect.DeepEqual(restoredData.Accounts, testAccounts) {
 		t.Errorf("Expected saved accounts and restored accounts to be equal. "+
-			"Stored: %#v, Restored: %#v", testAccounts, restoredData)
+			"Stored: %#v, Restored: %#v", testAccounts, restoredData.Accounts)
 	}
 }
 
@@ -192,3 +354,805 @@ func TestFile_FetchAll(t *testing.T) {
 		}
 	}
 }
+
+func TestFile_FindByFullDomain(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		FullDomain     string
+		ExpectedDomain string
+		ExpectedErr    error
+	}{
+		{
+			Name:           "found",
+			FullDomain:     "threeletter.agency",
+			ExpectedDomain: "threeletter.agency",
+		},
+		{
+			Name:           "found, different case and trailing dot",
+			FullDomain:     "LettuceEncrypt.org.",
+			ExpectedDomain: "lettuceencrypt.org",
+		},
+		{
+			Name:        "not found",
+			FullDomain:  "tossed.lettuceencrypt.org",
+			ExpectedErr: ErrDomainNotFound,
+		},
+	}
+
+	fs := NewFile(filepath.Join("testdata", "accounts.json"), 0o600)
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			domain, acct, err := fs.FindByFullDomain(context.Background(), tc.FullDomain)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			if domain != tc.ExpectedDomain {
+				t.Errorf("expected domain %q, got %q", tc.ExpectedDomain, domain)
+			}
+
+			if expected := testAccounts[tc.ExpectedDomain]; !reflect.DeepEqual(acct, expected) {
+				t.Errorf("expected account %#v, got %#v", expected, acct)
+			}
+		})
+	}
+}
+
+func TestFile_All(t *testing.T) {
+	ctx := context.Background()
+
+	fs := NewFile(filepath.Join("testdata", "mixed_accounts.json"), 0o600)
+
+	var domains []string
+
+	for domain, acct := range fs.All(ctx) {
+		if !reflect.DeepEqual(acct, fs.accounts[domain]) {
+			t.Errorf("expected domain %q to have account %#v, had %#v", domain, fs.accounts[domain], acct)
+		}
+
+		domains = append(domains, domain)
+	}
+
+	expected := []string{"example.com", "lettuceencrypt.org", "threeletter.agency"}
+	if !reflect.DeepEqual(domains, expected) {
+		t.Errorf("expected domains %v, got %v", expected, domains)
+	}
+
+	domains = nil
+
+	for domain := range fs.All(ctx) {
+		domains = append(domains, domain)
+
+		if len(domains) == 2 {
+			break
+		}
+	}
+
+	if !reflect.DeepEqual(domains, expected[:2]) {
+		t.Errorf("expected domains %v on early break, got %v", expected[:2], domains)
+	}
+}
+
+func TestFile_All_delete(t *testing.T) {
+	ctx := context.Background()
+
+	fs := NewFile(filepath.Join("testdata", "mixed_accounts.json"), 0o600)
+
+	var domains []string
+
+	for domain := range fs.All(ctx) {
+		domains = append(domains, domain)
+
+		// Deleting while iterating doesn't deadlock, and the deleted accounts are skipped.
+		err := fs.Delete(ctx, "threeletter.agency")
+		if err != nil && !errors.Is(err, ErrDomainNotFound) {
+			t.Fatalf("unexpected error deleting domain: %v", err)
+		}
+	}
+
+	expected := []string{"example.com", "lettuceencrypt.org"}
+	if !reflect.DeepEqual(domains, expected) {
+		t.Errorf("expected domains %v, got %v", expected, domains)
+	}
+}
+
+func TestFile_Delete(t *testing.T) {
+	ctx := context.Background()
+
+	storage := NewFile("", 0)
+
+	for d, acct := range testAccounts {
+		err := storage.Put(ctx, d, acct)
+		if err != nil {
+			t.Errorf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	err := storage.Delete(ctx, "lettuceencrypt.org")
+	if err != nil {
+		t.Fatalf("unexpected error deleting domain from storage: %v", err)
+	}
+
+	_, err = storage.Fetch(ctx, "lettuceencrypt.org")
+	if !errors.Is(err, ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound for Fetch of deleted domain, got %v", err)
+	}
+
+	_, err = storage.Fetch(ctx, "threeletter.agency")
+	if err != nil {
+		t.Errorf("unexpected error fetching remaining domain from storage: %v", err)
+	}
+
+	err = storage.Delete(ctx, "doesnt-exist.example.org")
+	if !errors.Is(err, ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound for Delete of non-existent domain, got %v", err)
+	}
+}
+
+func TestFile_Save_atomic(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "acmedns.account")
+
+	storage := NewFile(file, 0o600)
+
+	for d, acct := range testAccounts {
+		err := storage.Put(ctx, d, acct)
+		if err != nil {
+			t.Errorf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	err := storage.Save(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error saving storage: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || entries[0].Name() != "acmedns.account" {
+		t.Errorf("expected only the storage file in the directory, got %v", entries)
+	}
+
+	restored := NewFile(file, 0o600)
+
+	if !reflect.DeepEqual(restored.accounts, testAccounts) {
+		t.Errorf("expected to have accounts %#v loaded, had %#v", testAccounts, restored.accounts)
+	}
+}
+
+// cancelingFS is a [Filesystem] canceling a context once a file is written, to simulate a cancellation during a save.
+type cancelingFS struct {
+	*memFS
+
+	cancel context.CancelFunc
+}
+
+func (c cancelingFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	defer c.cancel()
+
+	return c.memFS.WriteFile(name, data, perm)
+}
+
+func TestFile_Save_canceled(t *testing.T) {
+	memfs := newMemFS()
+
+	storage := NewFile("accounts.json", 0o600, WithFilesystem(memfs))
+
+	err := storage.Put(context.Background(), "lettuceencrypt.org", testAccounts["lettuceencrypt.org"])
+	if err != nil {
+		t.Fatalf("unexpected error adding account to storage: %v", err)
+	}
+
+	err = storage.Save(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error saving storage: %v", err)
+	}
+
+	saved, _ := memfs.ReadFile("accounts.json")
+
+	err = storage.Put(context.Background(), "threeletter.agency", testAccounts["threeletter.agency"])
+	if err != nil {
+		t.Fatalf("unexpected error adding account to storage: %v", err)
+	}
+
+	for _, name := range []string{"before the save", "during the save"} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+
+			if name == "before the save" {
+				cancel()
+			}
+
+			storage.fsys = cancelingFS{memFS: memfs, cancel: cancel}
+
+			err := storage.Save(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+
+			if files := slices.Collect(maps.Keys(memfs.files)); !reflect.DeepEqual(files, []string{"accounts.json"}) {
+				t.Errorf("expected only the storage file, got %v", files)
+			}
+
+			if data, _ := memfs.ReadFile("accounts.json"); !bytes.Equal(data, saved) {
+				t.Errorf("expected the storage file to be unchanged, got %s", data)
+			}
+		})
+	}
+}
+
+func TestFile_Save_fsyncFallback(t *testing.T) {
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "acmedns.account")
+
+	storage := NewFile(file, 0o600, WithFsyncFallback())
+
+	for d, acct := range testAccounts {
+		err := storage.Put(ctx, d, acct)
+		if err != nil {
+			t.Errorf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	err := storage.Save(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error saving storage: %v", err)
+	}
+
+	for _, name := range []string{file, file + ".bak", file + ".bak.sha256", file + ".sha256"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("expected file %q to exist: %v", name, err)
+		}
+	}
+
+	restored := NewFile(file, 0o600, WithFsyncFallback())
+
+	if !reflect.DeepEqual(restored.accounts, testAccounts) {
+		t.Errorf("expected to have accounts %#v loaded, had %#v", testAccounts, restored.accounts)
+	}
+}
+
+func TestFile_Save_fsyncFallbackKeepsMode(t *testing.T) {
+	ctx := context.Background()
+
+	fsys := newMemFS()
+
+	err := fsys.WriteFile("acmedns.json", []byte(`{}`), 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewFile("acmedns.json", 0o600, WithFilesystem(fsys), WithFsyncFallback())
+	storage.accounts = maps.Clone(testAccounts)
+
+	err = storage.Save(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error saving storage: %v", err)
+	}
+
+	for _, name := range []string{"acmedns.json", "acmedns.json.bak", "acmedns.json.bak.sha256", "acmedns.json.sha256"} {
+		if perm := fsys.files[name].perm; perm != 0o640 {
+			t.Errorf("expected file %q to have the existing file mode %#o, got %#o", name, 0o640, perm)
+		}
+	}
+}
+
+func TestFile_load_fsyncFallbackRecovery(t *testing.T) {
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "acmedns.account")
+
+	storage := NewFile(file, 0o600, WithFsyncFallback())
+
+	for d, acct := range testAccounts {
+		err := storage.Put(ctx, d, acct)
+		if err != nil {
+			t.Errorf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	err := storage.Save(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error saving storage: %v", err)
+	}
+
+	// Simulate an interrupted in-place write.
+	err = os.WriteFile(file, []byte(`{"lettuceencrypt.org":{"fulldo`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := NewFile(file, 0o600, WithFsyncFallback())
+
+	if !reflect.DeepEqual(restored.accounts, testAccounts) {
+		t.Errorf("expected to have accounts %#v recovered, had %#v", testAccounts, restored.accounts)
+	}
+
+	// Corrupt the backup too: nothing can be recovered.
+	err = os.WriteFile(file+".bak", []byte(`{}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored = NewFile("", 0o600, WithFsyncFallback())
+	restored.path = file
+
+	err = restored.load(ctx)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+// crashingFS is a [Filesystem] simulating a crash during its n-th write:
+// the file is left half-written, and the following writes fail.
+type crashingFS struct {
+	*memFS
+
+	n      int
+	writes int
+}
+
+var errCrash = errors.New("crash")
+
+func (c *crashingFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	c.writes++
+
+	switch {
+	case c.writes < c.n:
+		return c.memFS.WriteFile(name, data, perm)
+	case c.writes == c.n:
+		_ = c.memFS.WriteFile(name, data[:len(data)/2], perm)
+	}
+
+	return errCrash
+}
+
+func TestFile_Save_fsyncFallbackCrash(t *testing.T) {
+	ctx := context.Background()
+
+	previous := map[string]goacmedns.Account{"lettuceencrypt.org": testAccounts["lettuceencrypt.org"]}
+
+	// Each save writes the backup, its checksum, the storage file and its checksum.
+	for n := 1; n <= 4; n++ {
+		t.Run(fmt.Sprintf("crash on write %d", n), func(t *testing.T) {
+			memfs := newMemFS()
+
+			storage := NewFile("accounts.json", 0o600, WithFilesystem(memfs), WithFsyncFallback())
+			storage.accounts = maps.Clone(previous)
+
+			err := storage.Save(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error saving storage: %v", err)
+			}
+
+			storage.accounts = maps.Clone(testAccounts)
+			storage.fsys = &crashingFS{memFS: memfs, n: n}
+
+			err = storage.Save(ctx)
+			if !errors.Is(err, errCrash) {
+				t.Fatalf("expected the crash error, got %v", err)
+			}
+
+			restored, err := OpenFile("accounts.json", 0o600, WithFilesystem(memfs), WithFsyncFallback())
+			if err != nil {
+				t.Fatalf("expected the storage to be recovered, got %v", err)
+			}
+
+			if !reflect.DeepEqual(restored.accounts, previous) && !reflect.DeepEqual(restored.accounts, testAccounts) {
+				t.Errorf("expected the previous or the saved accounts, got %#v", restored.accounts)
+			}
+		})
+	}
+}
+
+func TestFile_Save_concurrent(t *testing.T) {
+	storage := NewFile(filepath.Join(t.TempDir(), "acmedns.json"), 0o600)
+	storage.accounts = maps.Clone(testAccounts)
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs <- storage.Save(context.Background())
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error saving storage: %v", err)
+		}
+	}
+}
+
+func TestFile_Put_invalidAccount(t *testing.T) {
+	storage := NewFile("", 0)
+
+	err := storage.Put(context.Background(), "example.com", goacmedns.Account{Username: "user"})
+	if !errors.Is(err, goacmedns.ErrInvalidAccount) {
+		t.Errorf("expected ErrInvalidAccount, got %v", err)
+	}
+
+	_, err = storage.Fetch(context.Background(), "example.com")
+	if !errors.Is(err, ErrDomainNotFound) {
+		t.Errorf("expected invalid account not to be stored, got %v", err)
+	}
+}
+
+func TestFile_Reload(t *testing.T) {
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "acmedns.account")
+
+	storage := NewFile(file, 0o600)
+
+	err := storage.Reload(ctx)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist reloading a missing file, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join("testdata", "accounts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(file, data, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.Reload(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error reloading storage: %v", err)
+	}
+
+	for d, expected := range testAccounts {
+		acct, err := storage.Fetch(ctx, d)
+		if err != nil {
+			t.Errorf("unexpected error fetching domain %q from storage: %v", d, err)
+		}
+
+		if !reflect.DeepEqual(acct, expected) {
+			t.Errorf("expected domain %q to have account %#v, had %#v\n", d, expected, acct)
+		}
+	}
+
+	err = os.WriteFile(file, []byte(`{"malformed":`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.Reload(ctx)
+	if err == nil {
+		t.Error("expected an error reloading a malformed file, got nil")
+	}
+
+	allAccounts, err := storage.FetchAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(allAccounts, testAccounts) {
+		t.Errorf("expected accounts to be kept after a failed reload, had %#v", allAccounts)
+	}
+}
+
+func TestFile_Save_indent(t *testing.T) {
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "acmedns.json")
+
+	storage := NewFile(file, 0o600, WithIndent("", "  "))
+
+	for d, acct := range testAccounts {
+		err := storage.Put(ctx, d, acct)
+		if err != nil {
+			t.Errorf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	err := storage.Save(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error saving storage: %v", err)
+	}
+
+	storedJSON, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error reading stored JSON from %q: %v", file, err)
+	}
+
+	expected, err := json.MarshalIndent(fileContent{Version: fileVersion, Accounts: testAccounts}, "", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error marshaling test accounts: %v", err)
+	}
+
+	if !bytes.Equal(storedJSON, expected) {
+		t.Errorf("expected indented JSON:\n%s\ngot:\n%s", expected, storedJSON)
+	}
+
+	compactJSON, err := json.Marshal(testAccounts)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling test accounts: %v", err)
+	}
+
+	compactFile := filepath.Join(t.TempDir(), "compact.json")
+
+	err = os.WriteFile(compactFile, compactJSON, 0o600)
+	if err != nil {
+		t.Fatalf("unexpected error writing compact JSON to %q: %v", compactFile, err)
+	}
+
+	// Both indented and compact files are loaded.
+	for _, path := range []string{file, compactFile} {
+		loaded := NewFile(path, 0o600, WithIndent("", "  "))
+
+		if !reflect.DeepEqual(loaded.accounts, testAccounts) {
+			t.Errorf("expected to have accounts %#v loaded from %q, had %#v", testAccounts, path, loaded.accounts)
+		}
+	}
+}
+
+func TestNewYAMLFile_withAccounts(t *testing.T) {
+	fs := NewYAMLFile(filepath.Join("testdata", "accounts.yaml"), 0o600)
+
+	if !reflect.DeepEqual(fs.accounts, testAccounts) {
+		t.Errorf("expected to have accounts %#v loaded, had %#v", testAccounts, fs.accounts)
+	}
+}
+
+func TestNewYAMLFile_withLegacyData(t *testing.T) {
+	fs := NewYAMLFile(filepath.Join("testdata", "legacy_account.yaml"), 0o600)
+
+	legacyAcct, found := fs.accounts["threeletter.agency"]
+	if !found {
+		t.Fatalf("expected to find account but was unable to")
+	}
+
+	if legacyAcct.ServerURL != "" {
+		t.Errorf("expected empty Server string from legacy account, but got %s", legacyAcct.ServerURL)
+	}
+
+	// set the missing value for legacy account to be able to evaluate equivalence
+	legacyAcct.ServerURL = testAccounts["threeletter.agency"].ServerURL
+
+	if !reflect.DeepEqual(legacyAcct, testAccounts["threeletter.agency"]) {
+		t.Errorf("expected equivalent test and legacy accounts")
+	}
+}
+
+func TestYAMLFile_Save(t *testing.T) {
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "acmedns.yaml")
+
+	storage := NewYAMLFile(file, 0o600)
+
+	for d, acct := range testAccounts {
+		err := storage.Put(ctx, d, acct)
+		if err != nil {
+			t.Errorf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	err := storage.Save(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error saving storage: %v", err)
+	}
+
+	storedYAML, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("unexpected error reading stored YAML from %q: %v", file, err)
+	}
+
+	var restoredData fileContent
+
+	err = yaml.Unmarshal(storedYAML, &restoredData)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshaling stored YAML from %q: %v", file, err)
+	}
+
+	if restoredData.Version != fileVersion {
+		t.Errorf("expected stored version %d, got %d", fileVersion, restoredData.Version)
+	}
+
+	if !reflect.DeepEqual(restoredData.Accounts, testAccounts) {
+		t.Errorf("Expected saved accounts and restored accounts to be equal. "+
+			"Stored: %#v, Restored: %#v", testAccounts, restoredData.Accounts)
+	}
+}
+
+func TestFile_autoSave(t *testing.T) {
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "acmedns.account")
+
+	storage := NewFile(file, 0o600, WithAutoSave())
+
+	for d, acct := range testAccounts {
+		err := storage.Put(ctx, d, acct)
+		if err != nil {
+			t.Errorf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	restored := NewFile(file, 0o600)
+
+	if !reflect.DeepEqual(restored.accounts, testAccounts) {
+		t.Errorf("expected to have accounts %#v saved without an explicit Save, had %#v", testAccounts, restored.accounts)
+	}
+
+	err := storage.Delete(ctx, "lettuceencrypt.org")
+	if err != nil {
+		t.Fatalf("unexpected error deleting domain from storage: %v", err)
+	}
+
+	restored = NewFile(file, 0o600)
+
+	if _, found := restored.accounts["lettuceencrypt.org"]; found {
+		t.Error("expected the deleted account to be removed from disk without an explicit Save")
+	}
+}
+
+func TestFile_Save_allowFrom(t *testing.T) {
+	ctx := context.Background()
+
+	acct := testAccounts["lettuceencrypt.org"]
+	acct.AllowFrom = []string{"192.168.100.1/24", "2002:c0a8:2a00::0/40"}
+
+	for _, newFile := range []func(string, os.FileMode, ...FileOption) *File{NewFile, NewYAMLFile} {
+		file := filepath.Join(t.TempDir(), "acmedns.account")
+
+		storage := newFile(file, 0o600)
+
+		err := storage.Put(ctx, "lettuceencrypt.org", acct)
+		if err != nil {
+			t.Fatalf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+
+		err = storage.Save(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error saving storage: %v", err)
+		}
+
+		restored, err := newFile(file, 0o600).Fetch(ctx, "lettuceencrypt.org")
+		if err != nil {
+			t.Fatalf("unexpected error fetching restored account: %v", err)
+		}
+
+		if !reflect.DeepEqual(restored, acct) {
+			t.Errorf("expected restored account %#v, got %#v", acct, restored)
+		}
+	}
+}
+
+func TestFile_Migrate(t *testing.T) {
+	ctx := context.Background()
+
+	fs := NewFile(filepath.Join("testdata", "mixed_accounts.json"), 0o600)
+
+	_, err := fs.Migrate(ctx, "")
+	if !errors.Is(err, ErrEmptyServerURL) {
+		t.Errorf("expected ErrEmptyServerURL, got %v", err)
+	}
+
+	count, err := fs.Migrate(ctx, "https://legacy.example.org")
+	if err != nil {
+		t.Fatalf("unexpected error migrating accounts: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("expected 2 migrated accounts, got %d", count)
+	}
+
+	expected := map[string]string{
+		"lettuceencrypt.org": "https://auth.acme-dns.io",
+		"threeletter.agency": "https://legacy.example.org",
+		"example.com":        "https://legacy.example.org",
+	}
+
+	for domain, serverURL := range expected {
+		acct, err := fs.Fetch(ctx, domain)
+		if err != nil {
+			t.Fatalf("unexpected error fetching domain %q from storage: %v", domain, err)
+		}
+
+		if acct.ServerURL != serverURL {
+			t.Errorf("expected domain %q to have server URL %q, had %q", domain, serverURL, acct.ServerURL)
+		}
+	}
+
+	count, err = fs.Migrate(ctx, "https://legacy.example.org")
+	if err != nil {
+		t.Fatalf("unexpected error migrating accounts: %v", err)
+	}
+
+	if count != 0 {
+		t.Errorf("expected no migrated accounts on the second run, got %d", count)
+	}
+}
+
+func TestFile_Prune(t *testing.T) {
+	ctx := context.Background()
+
+	fs := NewFile(filepath.Join("testdata", "mixed_accounts.json"), 0o600)
+
+	count, err := fs.Prune(ctx, func(_ string, acct goacmedns.Account) bool {
+		return acct.ServerURL == ""
+	})
+	if err != nil {
+		t.Fatalf("unexpected error pruning accounts: %v", err)
+	}
+
+	if count != 2 {
+		t.Errorf("expected 2 pruned accounts, got %d", count)
+	}
+
+	accounts, err := fs.FetchAll(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error fetching all accounts: %v", err)
+	}
+
+	if _, found := accounts["lettuceencrypt.org"]; !found || len(accounts) != 1 {
+		t.Errorf("expected only lettuceencrypt.org to be kept, got %v", accounts)
+	}
+
+	count, err = fs.Prune(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error pruning accounts: %v", err)
+	}
+
+	if count != 1 {
+		t.Errorf("expected 1 pruned account, got %d", count)
+	}
+
+	if len(fs.accounts) != 0 {
+		t.Errorf("expected no accounts left, got %v", fs.accounts)
+	}
+}
+
+func TestFile_Save_keepsMode(t *testing.T) {
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "acmedns.account")
+
+	err := os.WriteFile(file, []byte(`{}`), 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chmod(file, 0o640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := NewFile(file, 0o600)
+
+	err = storage.Save(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error saving storage: %v", err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("expected the existing file mode %#o to be kept, got %#o", 0o640, info.Mode().Perm())
+	}
+}
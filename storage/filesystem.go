@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"io/fs"
+	"os"
+)
+
+// Filesystem is the filesystem used by [File] to read and write the storage file and its companion files.
+type Filesystem interface {
+	// ReadFile reads the named file and returns its contents.
+	// A missing file must be reported with an error wrapping [fs.ErrNotExist].
+	ReadFile(name string) ([]byte, error)
+	// WriteFile writes the data to the named file, creating it with the given permissions if required.
+	// The data must be durably written when it returns.
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	// Rename renames (moves) `oldpath` to `newpath`, replacing `newpath` if it already exists.
+	Rename(oldpath, newpath string) error
+	// Remove removes the named file.
+	Remove(name string) error
+	// Stat returns the [fs.FileInfo] describing the named file.
+	Stat(name string) (fs.FileInfo, error)
+}
+
+// WithFilesystem makes the [File] read and write through the given [Filesystem] instead of the OS filesystem,
+// e.g. to use an in-memory filesystem in tests.
+func WithFilesystem(fsys Filesystem) FileOption {
+	return func(f *File) {
+		f.fsys = fsys
+	}
+}
+
+// osFilesystem is the default [Filesystem], backed by the [os] package.
+type osFilesystem struct{}
+
+func (osFilesystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (osFilesystem) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return writeFileSync(name, data, perm)
+}
+
+func (osFilesystem) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+func (osFilesystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFilesystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"io/fs"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// memFS is an in-memory [Filesystem].
+type memFS struct {
+	files map[string]memFile
+}
+
+type memFile struct {
+	data []byte
+	perm fs.FileMode
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: make(map[string]memFile)}
+}
+
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	file, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return slices.Clone(file.data), nil
+}
+
+func (m *memFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	if file, ok := m.files[name]; ok {
+		perm = file.perm
+	}
+
+	m.files[name] = memFile{data: slices.Clone(data), perm: perm}
+
+	return nil
+}
+
+func (m *memFS) Rename(oldpath, newpath string) error {
+	file, ok := m.files[oldpath]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldpath, Err: fs.ErrNotExist}
+	}
+
+	delete(m.files, oldpath)
+	m.files[newpath] = file
+
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	delete(m.files, name)
+
+	return nil
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	file, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return memFileInfo{name: name, file: file}, nil
+}
+
+type memFileInfo struct {
+	name string
+	file memFile
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return int64(len(i.file.data)) }
+func (i memFileInfo) Mode() fs.FileMode  { return i.file.perm }
+func (i memFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memFileInfo) IsDir() bool        { return false }
+func (i memFileInfo) Sys() any           { return nil }
+
+func TestWithFilesystem(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Options       []FileOption
+		ExpectedFiles []string
+	}{
+		{
+			Name:          "atomic",
+			ExpectedFiles: []string{"acmedns.json"},
+		},
+		{
+			Name:          "fsync fallback",
+			Options:       []FileOption{WithFsyncFallback()},
+			ExpectedFiles: []string{"acmedns.json", "acmedns.json.bak", "acmedns.json.bak.sha256", "acmedns.json.sha256"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+
+			fsys := newMemFS()
+
+			storage := NewFile("acmedns.json", 0o600, append(tc.Options, WithFilesystem(fsys))...)
+
+			for d, acct := range testAccounts {
+				err := storage.Put(ctx, d, acct)
+				if err != nil {
+					t.Errorf("unexpected error adding account %#v to storage: %v", acct, err)
+				}
+			}
+
+			err := storage.Save(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error saving storage: %v", err)
+			}
+
+			files := make([]string, 0, len(fsys.files))
+			for name := range fsys.files {
+				files = append(files, name)
+			}
+
+			slices.Sort(files)
+
+			if !reflect.DeepEqual(files, tc.ExpectedFiles) {
+				t.Errorf("expected files %v, got %v", tc.ExpectedFiles, files)
+			}
+
+			if perm := fsys.files["acmedns.json"].perm; perm != 0o600 {
+				t.Errorf("expected file mode %o, got %o", 0o600, perm)
+			}
+
+			restored := NewFile("acmedns.json", 0o600, append(tc.Options, WithFilesystem(fsys))...)
+
+			if !reflect.DeepEqual(restored.accounts, testAccounts) {
+				t.Errorf("expected to have accounts %#v loaded, had %#v", testAccounts, restored.accounts)
+			}
+		})
+	}
+}
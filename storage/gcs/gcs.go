@@ -0,0 +1,140 @@
+// Package gcs provides a [goacmedns.Storage] implementation backed by an object of Google Cloud Storage.
+package gcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"sync"
+
+	gcstorage "cloud.google.com/go/storage"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+var _ goacmedns.Storage = (*Storage)(nil)
+
+// Storage implements the [goacmedns.Storage] interface and persists the accounts as a single JSON object in a GCS bucket,
+// in the same format as a [storage.File] (see [storage.EncodeJSON]), so that a storage file can be uploaded as is.
+// Like [storage.File], the accounts are kept in memory, and are only written to the object by [Storage.Save].
+// It is safe for concurrent use.
+type Storage struct {
+	// mu guards `accounts`.
+	mu       sync.RWMutex
+	object   *gcstorage.ObjectHandle
+	accounts map[string]goacmedns.Account
+}
+
+// New returns a [Storage] persisting the accounts into the given object of the bucket, and loads the accounts from it.
+// A missing object is treated as an empty storage, it is created by [Storage.Save].
+func New(ctx context.Context, client *gcstorage.Client, bucket, object string) (*Storage, error) {
+	s := &Storage{
+		object:   client.Bucket(bucket).Object(object),
+		accounts: make(map[string]goacmedns.Account),
+	}
+
+	err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// load reads and unmarshals the accounts from the object.
+func (s *Storage) load(ctx context.Context) error {
+	reader, err := s.object.NewReader(ctx)
+	if errors.Is(err, gcstorage.ErrObjectNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to open storage object: %w", err)
+	}
+
+	defer func() { _ = reader.Close() }()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read storage object: %w", err)
+	}
+
+	accounts, err := storage.DecodeJSON(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode storage object: %w", err)
+	}
+
+	s.accounts = accounts
+
+	return nil
+}
+
+// Save writes the accounts to the object.
+// The object is replaced atomically: a failed save leaves the previous content unchanged.
+func (s *Storage) Save(ctx context.Context) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := storage.EncodeJSON(s.accounts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal accounts: %w", err)
+	}
+
+	writer := s.object.NewWriter(ctx)
+	writer.ContentType = "application/json"
+
+	_, err = writer.Write(data)
+	if err != nil {
+		_ = writer.Close()
+
+		return fmt.Errorf("failed to write storage object: %w", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write storage object: %w", err)
+	}
+
+	return nil
+}
+
+// Put saves a [goacmedns.Account] for the given `domain` into the in-memory accounts.
+// The [goacmedns.Account] is checked with [goacmedns.Account.Validate] before being saved.
+// The [goacmedns.Account] data will not be written to the object until the [Storage.Save] function is called.
+func (s *Storage) Put(_ context.Context, domain string, acct goacmedns.Account) error {
+	err := acct.Validate()
+	if err != nil {
+		return fmt.Errorf("account for %q: %w", domain, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.accounts[domain] = acct
+
+	return nil
+}
+
+// Fetch retrieves the [goacmedns.Account] for the given `domain` from the in-memory accounts.
+// If the `domain` provided does not have a [goacmedns.Account] an [storage.ErrDomainNotFound] error is returned.
+func (s *Storage) Fetch(_ context.Context, domain string) (goacmedns.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if acct, exists := s.accounts[domain]; exists {
+		return acct, nil
+	}
+
+	return goacmedns.Account{}, storage.ErrDomainNotFound
+}
+
+// FetchAll returns a map that has domain names as its keys and [goacmedns.Account] objects as values.
+// The returned map is a copy and can be modified by the caller.
+func (s *Storage) FetchAll(_ context.Context) (map[string]goacmedns.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.accounts), nil
+}
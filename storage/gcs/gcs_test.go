@@ -0,0 +1,323 @@
+package gcs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	gcstorage "cloud.google.com/go/storage"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+	"google.golang.org/api/option"
+)
+
+var testAccounts = map[string]goacmedns.Account{
+	"lettuceencrypt.org": {
+		FullDomain: "lettuceencrypt.org",
+		SubDomain:  "tossed.lettuceencrypt.org",
+		Username:   "cpu",
+		Password:   "hunter2",
+		ServerURL:  "https://auth.acme-dns.io",
+		AllowFrom:  []string{"192.168.100.1/24"},
+	},
+	"threeletter.agency": {
+		FullDomain: "threeletter.agency",
+		SubDomain:  "jobs.threeletter.agency",
+		Username:   "spooky.mulder",
+		Password:   "trustno1",
+		ServerURL:  "https://example.org",
+	},
+}
+
+// fakeGCS is a fake GCS server, implementing the object download and the multipart upload of the JSON API.
+type fakeGCS struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeGCS) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.Method == http.MethodPost && strings.HasPrefix(req.URL.Path, "/upload/storage/v1/b/"):
+		f.upload(resp, req)
+	case req.Method == http.MethodGet:
+		f.download(resp, req)
+	default:
+		http.Error(resp, "unsupported request", http.StatusNotImplemented)
+	}
+}
+
+// download serves the objects at `/<bucket>/<object>` and `/storage/v1/b/<bucket>/o/<object>?alt=media`.
+func (f *fakeGCS) download(resp http.ResponseWriter, req *http.Request) {
+	key := strings.TrimPrefix(req.URL.Path, "/")
+
+	if rest, ok := strings.CutPrefix(key, "storage/v1/b/"); ok {
+		key = strings.Replace(rest, "/o/", "/", 1)
+	}
+
+	f.mu.Lock()
+	data, ok := f.objects[key]
+	f.mu.Unlock()
+
+	if !ok {
+		http.NotFound(resp, req)
+
+		return
+	}
+
+	_, _ = resp.Write(data)
+}
+
+func (f *fakeGCS) upload(resp http.ResponseWriter, req *http.Request) {
+	bucket := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/upload/storage/v1/b/"), "/o")
+
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	reader := multipart.NewReader(req.Body, params["boundary"])
+
+	var attrs struct {
+		Name string `json:"name"`
+	}
+
+	part, err := reader.NextPart()
+	if err == nil {
+		err = json.NewDecoder(part).Decode(&attrs)
+	}
+
+	if err == nil {
+		part, err = reader.NextPart()
+	}
+
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	data, err := io.ReadAll(part)
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	f.mu.Lock()
+	f.objects[bucket+"/"+attrs.Name] = data
+	f.mu.Unlock()
+
+	resp.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(resp).Encode(map[string]any{"bucket": bucket, "name": attrs.Name, "size": strconv.Itoa(len(data))})
+}
+
+func setupTest(t *testing.T) (*gcstorage.Client, *fakeGCS) {
+	t.Helper()
+
+	fake := &fakeGCS{objects: make(map[string][]byte)}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := gcstorage.NewClient(context.Background(),
+		option.WithEndpoint(server.URL+"/storage/v1/"),
+		option.WithoutAuthentication(),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error creating GCS client: %v", err)
+	}
+
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client, fake
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+
+	client, fake := setupTest(t)
+
+	st, err := New(ctx, client, "certs", "goacmedns/accounts.json")
+	if err != nil {
+		t.Fatalf("unexpected error creating storage: %v", err)
+	}
+
+	for domain, acct := range testAccounts {
+		err = st.Put(ctx, domain, acct)
+		if err != nil {
+			t.Fatalf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	if len(fake.objects) != 0 {
+		t.Errorf("expected no object to be written before Save, got %v", fake.objects)
+	}
+
+	err = st.Save(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error saving storage: %v", err)
+	}
+
+	if _, ok := fake.objects["certs/goacmedns/accounts.json"]; !ok {
+		t.Fatalf("expected the accounts to be written to the object, got %v", fake.objects)
+	}
+
+	loaded, err := New(ctx, client, "certs", "goacmedns/accounts.json")
+	if err != nil {
+		t.Fatalf("unexpected error loading storage: %v", err)
+	}
+
+	for domain, expected := range testAccounts {
+		acct, err := loaded.Fetch(ctx, domain)
+		if err != nil {
+			t.Fatalf("unexpected error fetching domain %q from storage: %v", domain, err)
+		}
+
+		if !reflect.DeepEqual(acct, expected) {
+			t.Errorf("expected domain %q to have account %#v, had %#v", domain, expected, acct)
+		}
+	}
+
+	accounts, err := loaded.FetchAll(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error fetching all accounts: %v", err)
+	}
+
+	if !reflect.DeepEqual(accounts, testAccounts) {
+		t.Errorf("expected accounts %#v, got %#v", testAccounts, accounts)
+	}
+}
+
+func TestStorage_Fetch_notFound(t *testing.T) {
+	client, _ := setupTest(t)
+
+	st, err := New(context.Background(), client, "certs", "accounts.json")
+	if err != nil {
+		t.Fatalf("unexpected error creating storage: %v", err)
+	}
+
+	_, err = st.Fetch(context.Background(), "doesnotexist.example.com")
+	if !errors.Is(err, storage.ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound, got %v", err)
+	}
+}
+
+func TestNew_invalidObject(t *testing.T) {
+	client, fake := setupTest(t)
+
+	fake.objects["certs/accounts.json"] = []byte("hunter2")
+
+	_, err := New(context.Background(), client, "certs", "accounts.json")
+	if err == nil {
+		t.Error("expected an error loading an invalid object, got nil")
+	}
+}
+
+func TestStorage_Put_invalidAccount(t *testing.T) {
+	client, _ := setupTest(t)
+
+	st, err := New(context.Background(), client, "certs", "accounts.json")
+	if err != nil {
+		t.Fatalf("unexpected error creating storage: %v", err)
+	}
+
+	err = st.Put(context.Background(), "example.com", goacmedns.Account{FullDomain: "example.com"})
+	if !errors.Is(err, goacmedns.ErrInvalidAccount) {
+		t.Errorf("expected ErrInvalidAccount, got %v", err)
+	}
+}
+
+func TestNew_storageFile(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "accounts.json")
+
+	file := storage.NewFile(path, 0o600)
+
+	for domain, acct := range testAccounts {
+		err := file.Put(ctx, domain, acct)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := file.Save(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versioned, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, err := json.Marshal(testAccounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		Name    string
+		Content []byte
+	}{
+		{Name: "uploaded storage file", Content: versioned},
+		{Name: "legacy format", Content: legacy},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			client, fake := setupTest(t)
+
+			fake.objects["certs/accounts.json"] = tc.Content
+
+			st, err := New(ctx, client, "certs", "accounts.json")
+			if err != nil {
+				t.Fatalf("unexpected error loading storage: %v", err)
+			}
+
+			accounts, err := st.FetchAll(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error fetching all accounts: %v", err)
+			}
+
+			if !reflect.DeepEqual(accounts, testAccounts) {
+				t.Errorf("expected accounts %#v, got %#v", testAccounts, accounts)
+			}
+
+			err = st.Save(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error saving storage: %v", err)
+			}
+
+			// The saved object is a valid storage file.
+			err = os.WriteFile(path, fake.objects["certs/accounts.json"], 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			restored, err := storage.OpenFile(path, 0o600)
+			if err != nil {
+				t.Fatalf("unexpected error loading the saved object as a storage file: %v", err)
+			}
+
+			accounts, _ = restored.FetchAll(ctx)
+			if !reflect.DeepEqual(accounts, testAccounts) {
+				t.Errorf("expected accounts %#v, got %#v", testAccounts, accounts)
+			}
+		})
+	}
+}
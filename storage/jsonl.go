@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// ErrInvalidRecord is returned by [Import] when a record doesn't hold a domain.
+var ErrInvalidRecord = errors.New("invalid record")
+
+// record is a line of the JSON lines format of [Export] and [Import].
+type record struct {
+	Domain string `json:"domain"`
+	// Label is the label of the account (see [File.PutLabeled]), omitted for the default label.
+	Label   string            `json:"label,omitempty"`
+	Account goacmedns.Account `json:"account"`
+}
+
+// Export writes the accounts of the `src` storage to `w` as JSON lines, sorted by domain,
+// e.g. `{"domain":"example.com","account":{...}}`, and returns the number of exported accounts.
+// The labeled accounts of a [File] are exported too, with their label (see [File.PutLabeled]).
+// The output is suitable for backups, and can be read back with [Import].
+func Export(ctx context.Context, w io.Writer, src goacmedns.Storage) (int, error) {
+	accounts, err := fetchAllKeys(ctx, src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch the accounts: %w", err)
+	}
+
+	encoder := json.NewEncoder(w)
+
+	var count int
+
+	for _, key := range slices.Sorted(maps.Keys(accounts)) {
+		domain, label := splitLabeledKey(key)
+
+		err = encoder.Encode(record{Domain: domain, Label: label, Account: accounts[key]})
+		if err != nil {
+			return count, fmt.Errorf("failed to write the account for %q: %w", domain, err)
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+// Import reads the accounts written by [Export] from `r` and puts them into the `dst` storage, then saves it.
+// It returns the number of accounts put into `dst`.
+// The domains already present in `dst` are skipped, unless `overwrite` is true, like with [CopyStorage].
+// Nothing is put into `dst` if any record can't be read.
+func Import(ctx context.Context, r io.Reader, dst goacmedns.Storage, overwrite bool) (int, error) {
+	accounts := make(map[string]goacmedns.Account)
+
+	decoder := json.NewDecoder(r)
+
+	for line := 1; ; line++ {
+		var rec record
+
+		err := decoder.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("failed to read record %d: %w", line, err)
+		}
+
+		if rec.Domain == "" {
+			return 0, fmt.Errorf("%w: record %d has no domain", ErrInvalidRecord, line)
+		}
+
+		accounts[LabeledKey(rec.Domain, rec.Label)] = rec.Account
+	}
+
+	return putAll(ctx, dst, accounts, overwrite)
+}
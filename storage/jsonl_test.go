@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExport_Import(t *testing.T) {
+	ctx := context.Background()
+
+	src := NewFile(filepath.Join("testdata", "accounts.json"), 0o600)
+
+	buf := new(bytes.Buffer)
+
+	count, err := Export(ctx, buf, src)
+	if err != nil {
+		t.Fatalf("unexpected error exporting accounts: %v", err)
+	}
+
+	if count != len(testAccounts) {
+		t.Errorf("expected %d exported accounts, got %d", len(testAccounts), count)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(testAccounts) || !strings.HasPrefix(lines[0], `{"domain":"lettuceencrypt.org","account":{`) {
+		t.Errorf("expected one sorted JSON object per account, got %q", buf.String())
+	}
+
+	dst := NewFile("dst.json", 0o600, WithFilesystem(newMemFS()))
+
+	count, err = Import(ctx, buf, dst, false)
+	if err != nil {
+		t.Fatalf("unexpected error importing accounts: %v", err)
+	}
+
+	if count != len(testAccounts) {
+		t.Errorf("expected %d imported accounts, got %d", len(testAccounts), count)
+	}
+
+	reloaded := NewFile("dst.json", 0o600, WithFilesystem(dst.fsys))
+
+	accounts, _ := reloaded.FetchAll(ctx)
+	if !reflect.DeepEqual(accounts, testAccounts) {
+		t.Errorf("expected accounts %v, got %v", testAccounts, accounts)
+	}
+}
+
+func TestImport_invalid(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Input       string
+		ExpectedErr error
+	}{
+		{
+			Name:        "missing domain",
+			Input:       `{"account":{"subdomain":"sub","username":"user","password":"secret"}}`,
+			ExpectedErr: ErrInvalidRecord,
+		},
+		{
+			Name:  "malformed line",
+			Input: `{"domain":"example.com","account":{"subdomain":"sub","username":"user","password":"secret"}}` + "\n{",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			dst := NewFile("dst.json", 0o600, WithFilesystem(newMemFS()))
+
+			_, err := Import(context.Background(), strings.NewReader(tc.Input), dst, false)
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+
+			if tc.ExpectedErr != nil && !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			accounts, _ := dst.FetchAll(context.Background())
+			if len(accounts) != 0 {
+				t.Errorf("expected no imported account, got %v", accounts)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// ErrEmptyKeyFile is returned by [LoadAccountWithKeyFile] when the key file doesn't contain a password.
+var ErrEmptyKeyFile = errors.New("key file is empty")
+
+// LoadAccountWithKeyFile returns a copy of the [goacmedns.Account] with the password read from the given file,
+// e.g. a Kubernetes or Docker secret, so that the password doesn't need to be stored with the account.
+// The leading and trailing whitespaces (including the final newline) of the file content are trimmed.
+//
+// The accounts without password are only supported in storage files written by hand or by other tools:
+// as [File.Put], [File.Update] and [File.Merge] validate the accounts, they reject them.
+// [Audit] reports them with a [FindingMissingPassword] warning.
+func LoadAccountWithKeyFile(acct goacmedns.Account, keyFilePath string) (goacmedns.Account, error) {
+	raw, err := os.ReadFile(keyFilePath)
+	if err != nil {
+		return goacmedns.Account{}, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	password := strings.TrimSpace(string(raw))
+	if password == "" {
+		return goacmedns.Account{}, fmt.Errorf("%w: %q", ErrEmptyKeyFile, keyFilePath)
+	}
+
+	acct.Password = password
+
+	return acct, nil
+}
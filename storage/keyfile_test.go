@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+func TestLoadAccountWithKeyFile(t *testing.T) {
+	acct := goacmedns.Account{
+		FullDomain: "lettuceencrypt.org",
+		SubDomain:  "tossed.lettuceencrypt.org",
+		Username:   "cpu",
+		ServerURL:  "https://auth.acme-dns.io",
+	}
+
+	dir := t.TempDir()
+
+	keyFile := filepath.Join(dir, "password")
+
+	err := os.WriteFile(keyFile, []byte("  hunter2\n"), 0o600)
+	if err != nil {
+		t.Fatalf("unexpected error writing key file: %v", err)
+	}
+
+	emptyKeyFile := filepath.Join(dir, "empty")
+
+	err = os.WriteFile(emptyKeyFile, []byte("\n"), 0o600)
+	if err != nil {
+		t.Fatalf("unexpected error writing key file: %v", err)
+	}
+
+	testCases := []struct {
+		Name             string
+		KeyFile          string
+		ExpectedPassword string
+		ExpectedErr      error
+	}{
+		{
+			Name:             "key file",
+			KeyFile:          keyFile,
+			ExpectedPassword: "hunter2",
+		},
+		{
+			Name:        "missing key file",
+			KeyFile:     filepath.Join(dir, "missing"),
+			ExpectedErr: fs.ErrNotExist,
+		},
+		{
+			Name:        "empty key file",
+			KeyFile:     emptyKeyFile,
+			ExpectedErr: ErrEmptyKeyFile,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			loaded, err := LoadAccountWithKeyFile(acct, tc.KeyFile)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			if tc.ExpectedErr != nil {
+				return
+			}
+
+			expected := acct
+			expected.Password = tc.ExpectedPassword
+
+			if !loaded.Equal(expected) {
+				t.Errorf("expected account %v, got %v", expected, loaded)
+			}
+		})
+	}
+}
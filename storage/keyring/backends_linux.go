@@ -0,0 +1,8 @@
+//go:build linux
+
+package keyring
+
+import "github.com/99designs/keyring"
+
+// defaultBackends are the keyring backends used by [Open], in order of preference.
+var defaultBackends = []keyring.BackendType{keyring.SecretServiceBackend, keyring.KWalletBackend}
@@ -0,0 +1,8 @@
+//go:build !(darwin || linux || windows)
+
+package keyring
+
+import "github.com/99designs/keyring"
+
+// defaultBackends are the keyring backends used by [Open]: none is supported on this platform.
+var defaultBackends []keyring.BackendType
@@ -0,0 +1,8 @@
+//go:build windows
+
+package keyring
+
+import "github.com/99designs/keyring"
+
+// defaultBackends are the keyring backends used by [Open].
+var defaultBackends = []keyring.BackendType{keyring.WinCredBackend}
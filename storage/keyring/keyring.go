@@ -0,0 +1,123 @@
+// Package keyring provides a [goacmedns.Storage] implementation backed by the keyring of the operating system
+// (macOS Keychain, Windows Credential Manager, Secret Service or KWallet on Linux).
+package keyring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/99designs/keyring"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+// ErrUnsupportedPlatform is returned by [Open] on the platforms without a supported keyring.
+var ErrUnsupportedPlatform = errors.New("no keyring is supported on this platform")
+
+var _ goacmedns.Storage = (*Storage)(nil)
+
+// Storage implements the [goacmedns.Storage] interface and persists the accounts in a keyring.
+// Each account is stored as JSON in an item keyed by domain, under the service of the keyring.
+type Storage struct {
+	keyring keyring.Keyring
+}
+
+// Open returns a [Storage] persisting the accounts under the given service of the keyring of the operating system.
+func Open(service string) (*Storage, error) {
+	if len(defaultBackends) == 0 {
+		return nil, ErrUnsupportedPlatform
+	}
+
+	kr, err := keyring.Open(keyring.Config{
+		ServiceName:     service,
+		AllowedBackends: defaultBackends,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open keyring: %w", err)
+	}
+
+	return New(kr), nil
+}
+
+// New returns a [Storage] persisting the accounts into the given keyring,
+// e.g. a [keyring.ArrayKeyring] for tests.
+func New(kr keyring.Keyring) *Storage {
+	return &Storage{keyring: kr}
+}
+
+// Save is a no-op: the accounts are written to the keyring by [Storage.Put].
+func (s *Storage) Save(_ context.Context) error {
+	return nil
+}
+
+// Put writes the [goacmedns.Account] for the given `domain` to the keyring.
+// The [goacmedns.Account] is checked with [goacmedns.Account.Validate] before being written.
+func (s *Storage) Put(_ context.Context, domain string, acct goacmedns.Account) error {
+	err := acct.Validate()
+	if err != nil {
+		return fmt.Errorf("account for %q: %w", domain, err)
+	}
+
+	data, err := json.Marshal(acct)
+	if err != nil {
+		return fmt.Errorf("failed to marshal account: %w", err)
+	}
+
+	err = s.keyring.Set(keyring.Item{
+		Key:         domain,
+		Data:        data,
+		Label:       "ACME-DNS account for " + domain,
+		Description: "ACME-DNS account",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put account for %q: %w", domain, err)
+	}
+
+	return nil
+}
+
+// Fetch retrieves the [goacmedns.Account] for the given `domain` from the keyring.
+// If the `domain` provided does not have a [goacmedns.Account] in the keyring an [storage.ErrDomainNotFound] error is returned.
+func (s *Storage) Fetch(_ context.Context, domain string) (goacmedns.Account, error) {
+	item, err := s.keyring.Get(domain)
+	if errors.Is(err, keyring.ErrKeyNotFound) {
+		return goacmedns.Account{}, storage.ErrDomainNotFound
+	}
+
+	if err != nil {
+		return goacmedns.Account{}, fmt.Errorf("failed to get account for %q: %w", domain, err)
+	}
+
+	var acct goacmedns.Account
+
+	err = json.Unmarshal(item.Data, &acct)
+	if err != nil {
+		return goacmedns.Account{}, fmt.Errorf("failed to unmarshal account for %q: %w", domain, err)
+	}
+
+	return acct, nil
+}
+
+// FetchAll enumerates the items of the keyring,
+// and returns a map that has domain names as its keys and [goacmedns.Account] objects as values.
+func (s *Storage) FetchAll(ctx context.Context) (map[string]goacmedns.Account, error) {
+	keys, err := s.keyring.Keys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	accounts := make(map[string]goacmedns.Account, len(keys))
+
+	for _, domain := range keys {
+		acct, err := s.Fetch(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+
+		accounts[domain] = acct
+	}
+
+	return accounts, nil
+}
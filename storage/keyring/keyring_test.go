@@ -0,0 +1,107 @@
+package keyring
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/99designs/keyring"
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+var testAccounts = map[string]goacmedns.Account{
+	"lettuceencrypt.org": {
+		FullDomain: "lettuceencrypt.org",
+		SubDomain:  "tossed.lettuceencrypt.org",
+		Username:   "cpu",
+		Password:   "hunter2",
+		ServerURL:  "https://auth.acme-dns.io",
+		AllowFrom:  []string{"192.168.100.1/24"},
+	},
+	"threeletter.agency": {
+		FullDomain: "threeletter.agency",
+		SubDomain:  "jobs.threeletter.agency",
+		Username:   "spooky.mulder",
+		Password:   "trustno1",
+		ServerURL:  "https://example.org",
+	},
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+
+	kr := keyring.NewArrayKeyring(nil)
+
+	st := New(kr)
+
+	for domain, acct := range testAccounts {
+		err := st.Put(ctx, domain, acct)
+		if err != nil {
+			t.Fatalf("unexpected error adding account %#v to storage: %v", acct, err)
+		}
+	}
+
+	if _, err := kr.Get("lettuceencrypt.org"); err != nil {
+		t.Errorf("expected the account to be stored under its domain, got %v", err)
+	}
+
+	for domain, expected := range testAccounts {
+		acct, err := st.Fetch(ctx, domain)
+		if err != nil {
+			t.Fatalf("unexpected error fetching domain %q from storage: %v", domain, err)
+		}
+
+		if !reflect.DeepEqual(acct, expected) {
+			t.Errorf("expected domain %q to have account %#v, had %#v", domain, expected, acct)
+		}
+	}
+
+	accounts, err := st.FetchAll(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error fetching all accounts: %v", err)
+	}
+
+	if !reflect.DeepEqual(accounts, testAccounts) {
+		t.Errorf("expected accounts %#v, got %#v", testAccounts, accounts)
+	}
+}
+
+func TestStorage_Fetch_notFound(t *testing.T) {
+	st := New(keyring.NewArrayKeyring(nil))
+
+	_, err := st.Fetch(context.Background(), "doesnotexist.example.com")
+	if !errors.Is(err, storage.ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound, got %v", err)
+	}
+}
+
+func TestStorage_Fetch_invalidItem(t *testing.T) {
+	st := New(keyring.NewArrayKeyring([]keyring.Item{{Key: "example.com", Data: []byte("hunter2")}}))
+
+	_, err := st.Fetch(context.Background(), "example.com")
+	if err == nil {
+		t.Error("expected an error fetching an invalid item, got nil")
+	}
+
+	_, err = st.FetchAll(context.Background())
+	if err == nil {
+		t.Error("expected an error fetching all the accounts with an invalid item, got nil")
+	}
+}
+
+func TestStorage_Put_invalidAccount(t *testing.T) {
+	kr := keyring.NewArrayKeyring(nil)
+	st := New(kr)
+
+	err := st.Put(context.Background(), "example.com", goacmedns.Account{FullDomain: "example.com"})
+	if !errors.Is(err, goacmedns.ErrInvalidAccount) {
+		t.Errorf("expected ErrInvalidAccount, got %v", err)
+	}
+
+	keys, _ := kr.Keys()
+	if len(keys) != 0 {
+		t.Errorf("expected no item to be written, got %v", keys)
+	}
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"maps"
+	"strings"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// labelSeparator separates the domain from the label in the keys of the labeled accounts.
+// It can't appear in a domain name, so the keys never collide with the unlabeled accounts.
+const labelSeparator = "#"
+
+// LabeledKey returns the key of the [goacmedns.Account] stored for the given `domain` and `label`,
+// e.g. `example.com#wildcard`, as found in the storage file and in the map given to [File.Update].
+// The default (empty) label is the `domain` itself, used by [File.Put] and [File.Fetch].
+func LabeledKey(domain, label string) string {
+	if label == "" {
+		return domain
+	}
+
+	return domain + labelSeparator + label
+}
+
+// PutLabeled saves a [goacmedns.Account] for the given `domain` and `label` like [File.Put],
+// e.g. to keep separate accounts for the apex and the wildcard of the same domain.
+// The empty label is the default label, whose account is also accessible with [File.Put] and [File.Fetch].
+func (f *File) PutLabeled(ctx context.Context, domain, label string, acct goacmedns.Account) error {
+	return f.Put(ctx, LabeledKey(domain, label), acct)
+}
+
+// FetchLabeled retrieves the [goacmedns.Account] for the given `domain` and `label` like [File.Fetch].
+// If the `domain` provided does not have a [goacmedns.Account] with this `label` in the storage
+// an [ErrDomainNotFound] error is returned.
+func (f *File) FetchLabeled(ctx context.Context, domain, label string) (goacmedns.Account, error) {
+	return f.Fetch(ctx, LabeledKey(domain, label))
+}
+
+// FetchAllLabeled retrieves all the [goacmedns.Account] objects from the File, by domain and by label.
+// Unlike [File.FetchAll], the labeled accounts are included; the accounts of the default label are under the empty label.
+func (f *File) FetchAllLabeled(_ context.Context) (map[string]map[string]goacmedns.Account, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	accounts := make(map[string]map[string]goacmedns.Account)
+
+	for key, acct := range f.accounts {
+		domain, label := splitLabeledKey(key)
+
+		if accounts[domain] == nil {
+			accounts[domain] = make(map[string]goacmedns.Account)
+		}
+
+		accounts[domain][label] = acct
+	}
+
+	return accounts, nil
+}
+
+// isLabeledKey reports whether the key is the key of an account with a non-default label (see [LabeledKey]).
+func isLabeledKey(key string) bool {
+	return strings.Contains(key, labelSeparator)
+}
+
+// splitLabeledKey returns the domain and the label of the key (see [LabeledKey]).
+func splitLabeledKey(key string) (domain, label string) {
+	domain, label, _ = strings.Cut(key, labelSeparator)
+
+	return domain, label
+}
+
+// fetchAllKeys retrieves all the accounts of the storage by key,
+// including the labeled accounts of a [File] under their [LabeledKey], e.g. to copy or export them.
+func fetchAllKeys(ctx context.Context, st goacmedns.Storage) (map[string]goacmedns.Account, error) {
+	f, ok := st.(*File)
+	if !ok {
+		return st.FetchAll(ctx)
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return maps.Clone(f.accounts), nil
+}
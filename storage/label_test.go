@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+func TestFile_PutLabeled(t *testing.T) {
+	ctx := context.Background()
+
+	apex := testAccounts["lettuceencrypt.org"]
+
+	wildcard := apex
+	wildcard.SubDomain = "wildcard.lettuceencrypt.org"
+	wildcard.Username = "wildcpu"
+
+	path := filepath.Join(t.TempDir(), "accounts.json")
+
+	fs := NewFile(path, 0o600)
+
+	err := fs.Put(ctx, "lettuceencrypt.org", apex)
+	if err != nil {
+		t.Fatalf("unexpected error putting the apex account: %v", err)
+	}
+
+	err = fs.PutLabeled(ctx, "lettuceencrypt.org", "wildcard", wildcard)
+	if err != nil {
+		t.Fatalf("unexpected error putting the wildcard account: %v", err)
+	}
+
+	err = fs.Save(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error saving: %v", err)
+	}
+
+	loaded := NewFile(path, 0o600)
+
+	testCases := []struct {
+		Name     string
+		Label    string
+		Expected goacmedns.Account
+	}{
+		{Name: "default label", Label: "", Expected: apex},
+		{Name: "wildcard label", Label: "wildcard", Expected: wildcard},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			acct, err := loaded.FetchLabeled(ctx, "lettuceencrypt.org", tc.Label)
+			if err != nil {
+				t.Fatalf("unexpected error fetching account: %v", err)
+			}
+
+			if !reflect.DeepEqual(acct, tc.Expected) {
+				t.Errorf("expected account %v, got %v", tc.Expected, acct)
+			}
+		})
+	}
+
+	acct, err := loaded.Fetch(ctx, "lettuceencrypt.org")
+	if err != nil || !reflect.DeepEqual(acct, apex) {
+		t.Errorf("expected Fetch to return the default label account %v, got %v (%v)", apex, acct, err)
+	}
+
+	_, err = loaded.FetchLabeled(ctx, "lettuceencrypt.org", "other")
+	if !errors.Is(err, ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound for an unknown label, got %v", err)
+	}
+
+	// The labeled accounts aren't listed as domains.
+	accounts, _ := loaded.FetchAll(ctx)
+
+	expected := map[string]goacmedns.Account{"lettuceencrypt.org": apex}
+
+	if !reflect.DeepEqual(accounts, expected) {
+		t.Errorf("expected accounts %v, got %v", expected, accounts)
+	}
+
+	labeled, _ := loaded.FetchAllLabeled(ctx)
+
+	expectedLabeled := map[string]map[string]goacmedns.Account{
+		"lettuceencrypt.org": {"": apex, "wildcard": wildcard},
+	}
+
+	if !reflect.DeepEqual(labeled, expectedLabeled) {
+		t.Errorf("expected labeled accounts %v, got %v", expectedLabeled, labeled)
+	}
+}
+
+func TestExportImport_labeled(t *testing.T) {
+	ctx := context.Background()
+
+	wildcard := testAccounts["lettuceencrypt.org"]
+	wildcard.SubDomain = "wildcard.lettuceencrypt.org"
+
+	src := NewFile("accounts.json", 0o600, WithFilesystem(newMemFS()))
+
+	err := src.PutLabeled(ctx, "lettuceencrypt.org", "wildcard", wildcard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+
+	_, err = Export(ctx, buf, src)
+	if err != nil {
+		t.Fatalf("unexpected error exporting accounts: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `"domain":"lettuceencrypt.org","label":"wildcard"`) {
+		t.Errorf("expected the label to be exported, got %s", buf.String())
+	}
+
+	dst := NewFile("accounts.json", 0o600, WithFilesystem(newMemFS()))
+
+	count, err := Import(ctx, buf, dst, false)
+	if err != nil {
+		t.Fatalf("unexpected error importing accounts: %v", err)
+	}
+
+	if count != 1 {
+		t.Errorf("expected 1 imported account, got %d", count)
+	}
+
+	acct, err := dst.FetchLabeled(ctx, "lettuceencrypt.org", "wildcard")
+	if err != nil || !acct.Equal(wildcard) {
+		t.Errorf("expected the labeled account %v, got %v (%v)", wildcard, acct, err)
+	}
+
+	// The labeled account is already present.
+	count, err = CopyStorage(ctx, dst, src, false)
+	if err != nil || count != 0 {
+		t.Errorf("expected no copied account, got %d (%v)", count, err)
+	}
+}
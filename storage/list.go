@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// ErrInvalidSortField is returned from [File.List] when the provided sort field is not supported.
+var ErrInvalidSortField = errors.New("invalid sort field")
+
+// ErrInvalidPage is returned from [File.List] when the provided offset or limit is negative.
+var ErrInvalidPage = errors.New("invalid page")
+
+// The fields the accounts can be sorted by with [File.List].
+const (
+	SortByDomain     = "domain"
+	SortByFullDomain = "fulldomain"
+	SortByServerURL  = "server_url"
+	SortByCreatedAt  = "created_at"
+	SortByLastUsedAt = "last_used_at"
+)
+
+// DomainAccount is a [goacmedns.Account] with the domain it is stored for.
+type DomainAccount struct {
+	Domain  string
+	Account goacmedns.Account
+}
+
+// List returns a page of at most `limit` accounts (all the remaining accounts if `limit` is 0),
+// starting at `offset` in the accounts sorted by the given field, and the total number of accounts.
+// The `sortBy` field is one of the SortBy constants ([SortByDomain] if empty),
+// and the accounts with the same value are sorted by domain.
+// An offset past the last account returns an empty page.
+// Like with [File.FetchAll], the accounts with a non-default label are not included.
+func (f *File) List(_ context.Context, offset, limit int, sortBy string) ([]DomainAccount, int, error) {
+	if offset < 0 || limit < 0 {
+		return nil, 0, fmt.Errorf("%w: offset %d, limit %d", ErrInvalidPage, offset, limit)
+	}
+
+	compare, err := compareBy(sortBy)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f.mu.RLock()
+
+	accounts := make([]DomainAccount, 0, len(f.accounts))
+	for domain, acct := range f.accounts {
+		if isLabeledKey(domain) {
+			continue
+		}
+
+		accounts = append(accounts, DomainAccount{Domain: domain, Account: acct})
+	}
+
+	f.mu.RUnlock()
+
+	slices.SortFunc(accounts, func(a, b DomainAccount) int {
+		return cmp.Or(compare(a, b), strings.Compare(a.Domain, b.Domain))
+	})
+
+	total := len(accounts)
+
+	start := min(offset, total)
+
+	end := total
+	if limit > 0 && limit < total-start {
+		end = start + limit
+	}
+
+	return accounts[start:end], total, nil
+}
+
+// compareBy returns the function comparing the accounts by the given field.
+func compareBy(sortBy string) (func(a, b DomainAccount) int, error) {
+	switch sortBy {
+	case "", SortByDomain:
+		return func(DomainAccount, DomainAccount) int { return 0 }, nil
+	case SortByFullDomain:
+		return func(a, b DomainAccount) int { return strings.Compare(a.Account.FullDomain, b.Account.FullDomain) }, nil
+	case SortByServerURL:
+		return func(a, b DomainAccount) int { return strings.Compare(a.Account.ServerURL, b.Account.ServerURL) }, nil
+	case SortByCreatedAt:
+		return func(a, b DomainAccount) int { return a.Account.CreatedAt.Compare(b.Account.CreatedAt) }, nil
+	case SortByLastUsedAt:
+		return func(a, b DomainAccount) int { return a.Account.LastUsedAt.Compare(b.Account.LastUsedAt) }, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSortField, sortBy)
+	}
+}
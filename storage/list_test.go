@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFile_List(t *testing.T) {
+	ctx := context.Background()
+
+	base := testAccounts["lettuceencrypt.org"]
+	now := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	fs := NewFile("accounts.json", 0o600, WithFilesystem(newMemFS()))
+
+	for i, domain := range []string{"d.example.com", "b.example.com", "a.example.com", "c.example.com", "e.example.com"} {
+		acct := base
+		acct.FullDomain = domain
+		// The created times are in the reverse order of the domains, except for e.example.com created with c.example.com.
+		acct.CreatedAt = now.Add(-time.Duration(min(i, 3)) * time.Hour)
+
+		err := fs.Put(ctx, domain, acct)
+		if err != nil {
+			t.Fatalf("unexpected error adding account to storage: %v", err)
+		}
+	}
+
+	testCases := []struct {
+		Name            string
+		Offset          int
+		Limit           int
+		SortBy          string
+		ExpectedDomains []string
+	}{
+		{
+			Name:            "all",
+			ExpectedDomains: []string{"a.example.com", "b.example.com", "c.example.com", "d.example.com", "e.example.com"},
+		},
+		{
+			Name:            "first page",
+			Limit:           2,
+			ExpectedDomains: []string{"a.example.com", "b.example.com"},
+		},
+		{
+			Name:            "last page",
+			Offset:          4,
+			Limit:           2,
+			ExpectedDomains: []string{"e.example.com"},
+		},
+		{
+			Name:            "exact last page",
+			Offset:          3,
+			Limit:           2,
+			ExpectedDomains: []string{"d.example.com", "e.example.com"},
+		},
+		{
+			Name:            "past the end",
+			Offset:          5,
+			Limit:           2,
+			ExpectedDomains: []string{},
+		},
+		{
+			Name:            "sorted by creation time",
+			SortBy:          SortByCreatedAt,
+			ExpectedDomains: []string{"c.example.com", "e.example.com", "a.example.com", "b.example.com", "d.example.com"},
+		},
+		{
+			Name:            "sorted by full domain",
+			Offset:          1,
+			Limit:           3,
+			SortBy:          SortByFullDomain,
+			ExpectedDomains: []string{"b.example.com", "c.example.com", "d.example.com"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			page, total, err := fs.List(ctx, tc.Offset, tc.Limit, tc.SortBy)
+			if err != nil {
+				t.Fatalf("unexpected error listing accounts: %v", err)
+			}
+
+			if total != 5 {
+				t.Errorf("expected a total of 5 accounts, got %d", total)
+			}
+
+			domains := []string{}
+
+			for _, da := range page {
+				domains = append(domains, da.Domain)
+
+				if da.Account.FullDomain != da.Domain {
+					t.Errorf("expected the account of %q, got %#v", da.Domain, da.Account)
+				}
+			}
+
+			if !reflect.DeepEqual(domains, tc.ExpectedDomains) {
+				t.Errorf("expected domains %v, got %v", tc.ExpectedDomains, domains)
+			}
+		})
+	}
+}
+
+func TestFile_List_errors(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Offset      int
+		Limit       int
+		SortBy      string
+		ExpectedErr error
+	}{
+		{Name: "negative offset", Offset: -1, ExpectedErr: ErrInvalidPage},
+		{Name: "negative limit", Limit: -1, ExpectedErr: ErrInvalidPage},
+		{Name: "unknown sort field", SortBy: "password", ExpectedErr: ErrInvalidSortField},
+	}
+
+	fs := NewFile("accounts.json", 0o600, WithFilesystem(newMemFS()))
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, _, err := fs.List(context.Background(), tc.Offset, tc.Limit, tc.SortBy)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
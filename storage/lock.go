@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	lockSuffix = ".lock"
+
+	// lockRetryInterval is the interval between the attempts to acquire a lock held by another process.
+	lockRetryInterval = 10 * time.Millisecond
+
+	// loadLockTimeout bounds the wait for the lock when [NewFile] and [OpenFile] load the storage file.
+	loadLockTimeout = 10 * time.Second
+)
+
+// ErrLockUnsupported is returned when the file locking enabled with [WithFileLock] isn't supported on the platform.
+var ErrLockUnsupported = errors.New("file locking is not supported on this platform")
+
+// WithFileLock makes the [File] acquire an advisory lock shared across processes while reading and writing the storage file:
+// an exclusive lock when saving, and a shared lock when loading.
+// The lock is held on a `.lock` file next to the storage file, on the OS filesystem (even with [WithFilesystem]).
+// The acquisition of the lock is bounded by the context of the [File] method,
+// and by a 10 seconds timeout when loading the storage file in [NewFile] and [OpenFile].
+// It uses `flock` on Unix and `LockFileEx` on Windows.
+//
+// The locks of [File.Save] and of the loading don't span a load, change and save sequence:
+// when several processes change the same storage file, use [File.Update] so that their changes aren't overwritten.
+func WithFileLock() FileOption {
+	return func(f *File) {
+		f.fileLock = true
+	}
+}
+
+// lockFile acquires an advisory lock on the lock file of `path`, waiting until the context is done.
+// It returns a function releasing the lock.
+func lockFile(ctx context.Context, path string, exclusive bool) (func() error, error) {
+	file, err := os.OpenFile(path+lockSuffix, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	for {
+		locked, err := tryLock(file, exclusive)
+		if err != nil {
+			_ = file.Close()
+
+			return nil, fmt.Errorf("failed to lock storage file: %w", err)
+		}
+
+		if locked {
+			return func() error {
+				return errors.Join(unlock(file), file.Close())
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = file.Close()
+
+			return nil, fmt.Errorf("failed to lock storage file: %w", ctx.Err())
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package storage
+
+import "os"
+
+func tryLock(_ *os.File, _ bool) (bool, error) {
+	return false, ErrLockUnsupported
+}
+
+func unlock(_ *os.File) error {
+	return ErrLockUnsupported
+}
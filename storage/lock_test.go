@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+func TestWithFileLock(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "acmedns.json")
+
+	storage := NewFile(file, 0o600, WithFileLock())
+
+	// Another process holding an exclusive lock.
+	release, err := lockFile(context.Background(), file, true)
+	if errors.Is(err, ErrLockUnsupported) {
+		t.Skip(err)
+	}
+
+	if err != nil {
+		t.Fatalf("unexpected error locking the storage file: %v", err)
+	}
+
+	err = storage.Put(context.Background(), "lettuceencrypt.org", testAccounts["lettuceencrypt.org"])
+	if err != nil {
+		t.Fatalf("unexpected error adding account to storage: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = storage.Save(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded while the lock is held, got %v", err)
+	}
+
+	err = storage.Reload(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded while the lock is held, got %v", err)
+	}
+
+	err = release()
+	if err != nil {
+		t.Fatalf("unexpected error releasing the lock: %v", err)
+	}
+
+	err = storage.Save(context.Background())
+	if err != nil {
+		t.Errorf("unexpected error saving storage: %v", err)
+	}
+}
+
+func TestWithFileLock_concurrent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "acmedns.json")
+
+	release, err := lockFile(context.Background(), file, false)
+	if errors.Is(err, ErrLockUnsupported) {
+		t.Skip(err)
+	}
+
+	_ = release()
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+
+	// Each File stands for a process: they only share the storage file on disk.
+	for i := range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			storage := NewFile(file, 0o600, WithFileLock())
+
+			acct := goacmedns.Account{
+				FullDomain: fmt.Sprintf("%d.example.com", i),
+				SubDomain:  fmt.Sprintf("sub%d", i),
+				Username:   "user",
+				Password:   "secret",
+			}
+
+			errs <- storage.Update(context.Background(), func(accounts map[string]goacmedns.Account) error {
+				accounts[acct.FullDomain] = acct
+
+				return nil
+			})
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("unexpected error saving storage: %v", err)
+		}
+	}
+
+	restored := NewFile(file, 0o600, WithFileLock())
+
+	err = restored.Reload(context.Background())
+	if err != nil {
+		t.Fatalf("expected a valid storage file, got %v", err)
+	}
+
+	if len(restored.accounts) != 10 {
+		t.Errorf("expected the accounts of all the updates, got %d", len(restored.accounts))
+	}
+
+	for domain, acct := range restored.accounts {
+		if acct.FullDomain != domain {
+			t.Errorf("expected a consistent account for domain %q, got %v", domain, acct)
+		}
+	}
+}
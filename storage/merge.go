@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// ErrUnknownMergeStrategy is returned from [File.Merge] when the provided strategy is not supported.
+var ErrUnknownMergeStrategy = errors.New("unknown merge strategy")
+
+// MergeStrategy defines how [File.Merge] resolves the domains present in both the in-memory and the incoming accounts.
+type MergeStrategy int
+
+const (
+	// PreferExisting keeps the in-memory account of the domains present in both.
+	PreferExisting MergeStrategy = iota
+	// PreferIncoming replaces the in-memory account of the domains present in both with the incoming account.
+	PreferIncoming
+)
+
+// String returns the name of the strategy.
+func (s MergeStrategy) String() string {
+	switch s {
+	case PreferExisting:
+		return "PreferExisting"
+	case PreferIncoming:
+		return "PreferIncoming"
+	default:
+		return fmt.Sprintf("MergeStrategy(%d)", int(s))
+	}
+}
+
+// Merge adds the `other` accounts to the in-memory accounts of the file instance,
+// resolving the domains present in both with the given `strategy`.
+// Unlike [File.Reload], the in-memory accounts missing from `other` are kept,
+// allowing to reconcile with the accounts read from another source without losing the unsaved changes.
+// The incoming accounts are checked with [goacmedns.Account.Validate]: if any is invalid, nothing is merged.
+// The changes will not be written to disk until the [File.Save] function is called,
+// unless the file was created with [WithAutoSave].
+func (f *File) Merge(ctx context.Context, other map[string]goacmedns.Account, strategy MergeStrategy) error {
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	if strategy != PreferExisting && strategy != PreferIncoming {
+		return fmt.Errorf("%w: %v", ErrUnknownMergeStrategy, strategy)
+	}
+
+	for domain, acct := range other {
+		err := acct.Validate()
+		if err != nil {
+			return fmt.Errorf("account for %q: %w", domain, err)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var changed bool
+
+	for domain, acct := range other {
+		if _, exists := f.accounts[domain]; exists && strategy == PreferExisting {
+			continue
+		}
+
+		f.accounts[domain] = acct
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return f.autoSaveIfEnabled(ctx)
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+func TestFile_Merge(t *testing.T) {
+	existing := testAccounts["lettuceencrypt.org"]
+
+	incoming := existing
+	incoming.Password = "hunter3"
+
+	other := testAccounts["threeletter.agency"]
+
+	testCases := []struct {
+		Name     string
+		Strategy MergeStrategy
+		Expected map[string]goacmedns.Account
+	}{
+		{
+			Name:     "prefer existing",
+			Strategy: PreferExisting,
+			Expected: map[string]goacmedns.Account{
+				"lettuceencrypt.org":  existing,
+				"threeletter.agency":  other,
+				"unsaved.example.com": existing,
+			},
+		},
+		{
+			Name:     "prefer incoming",
+			Strategy: PreferIncoming,
+			Expected: map[string]goacmedns.Account{
+				"lettuceencrypt.org":  incoming,
+				"threeletter.agency":  other,
+				"unsaved.example.com": existing,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+
+			fs := NewFile("accounts.json", 0o600, WithFilesystem(newMemFS()))
+
+			for _, domain := range []string{"lettuceencrypt.org", "unsaved.example.com"} {
+				err := fs.Put(ctx, domain, existing)
+				if err != nil {
+					t.Fatalf("unexpected error adding account to storage: %v", err)
+				}
+			}
+
+			err := fs.Merge(ctx, map[string]goacmedns.Account{
+				"lettuceencrypt.org": incoming,
+				"threeletter.agency": other,
+			}, tc.Strategy)
+			if err != nil {
+				t.Fatalf("unexpected error merging accounts: %v", err)
+			}
+
+			if !reflect.DeepEqual(fs.accounts, tc.Expected) {
+				t.Errorf("expected accounts %#v, got %#v", tc.Expected, fs.accounts)
+			}
+		})
+	}
+}
+
+func TestFile_Merge_errors(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Other       map[string]goacmedns.Account
+		Strategy    MergeStrategy
+		ExpectedErr error
+	}{
+		{
+			Name:        "unknown strategy",
+			Other:       testAccounts,
+			Strategy:    MergeStrategy(42),
+			ExpectedErr: ErrUnknownMergeStrategy,
+		},
+		{
+			Name: "invalid account",
+			Other: map[string]goacmedns.Account{
+				"lettuceencrypt.org":  testAccounts["lettuceencrypt.org"],
+				"invalid.example.com": {FullDomain: "invalid.example.com"},
+			},
+			Strategy:    PreferIncoming,
+			ExpectedErr: goacmedns.ErrInvalidAccount,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			fs := NewFile("accounts.json", 0o600, WithFilesystem(newMemFS()))
+
+			err := fs.Merge(context.Background(), tc.Other, tc.Strategy)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			// Nothing is merged on error.
+			if len(fs.accounts) != 0 {
+				t.Errorf("expected no accounts, got %#v", fs.accounts)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Package metrics exposes statistics about the content of a [goacmedns.Storage] as Prometheus metrics.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultTimeout bounds the time spent fetching the accounts on each collection.
+const defaultTimeout = 30 * time.Second
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// Collector implements [prometheus.Collector] and exposes the [storage.StorageStats] of a [goacmedns.Storage] as gauges.
+// The accounts are fetched from the storage on every collection, so the gauges always reflect the current content.
+type Collector struct {
+	storage goacmedns.Storage
+
+	total     *prometheus.Desc
+	perServer *prometheus.Desc
+	legacy    *prometheus.Desc
+}
+
+// NewCollector returns a [Collector] for the given storage.
+// It must be registered with a [prometheus.Registerer] to be exposed.
+func NewCollector(st goacmedns.Storage) *Collector {
+	return &Collector{
+		storage: st,
+		total: prometheus.NewDesc(
+			"goacmedns_storage_accounts",
+			"Number of ACME-DNS accounts in the storage.",
+			nil, nil),
+		perServer: prometheus.NewDesc(
+			"goacmedns_storage_server_accounts",
+			"Number of ACME-DNS accounts in the storage per server URL.",
+			[]string{"server_url"}, nil),
+		legacy: prometheus.NewDesc(
+			"goacmedns_storage_legacy_accounts",
+			"Number of ACME-DNS accounts in the storage without a server URL.",
+			nil, nil),
+	}
+}
+
+// Describe implements [prometheus.Collector].
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.total
+	ch <- c.perServer
+	ch <- c.legacy
+}
+
+// Collect implements [prometheus.Collector].
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	accounts, err := c.storage.FetchAll(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.total, err)
+
+		return
+	}
+
+	stats := storage.Stats(accounts)
+
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stats.Total))
+	ch <- prometheus.MustNewConstMetric(c.legacy, prometheus.GaugeValue, float64(stats.Legacy))
+
+	for serverURL, count := range stats.PerServer {
+		ch <- prometheus.MustNewConstMetric(c.perServer, prometheus.GaugeValue, float64(count), serverURL)
+	}
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nrdcg/goacmedns/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	st := storage.NewFile(filepath.Join("..", "testdata", "mixed_accounts.json"), 0o600)
+
+	expected := `
+# HELP goacmedns_storage_accounts Number of ACME-DNS accounts in the storage.
+# TYPE goacmedns_storage_accounts gauge
+goacmedns_storage_accounts 3
+# HELP goacmedns_storage_legacy_accounts Number of ACME-DNS accounts in the storage without a server URL.
+# TYPE goacmedns_storage_legacy_accounts gauge
+goacmedns_storage_legacy_accounts 2
+# HELP goacmedns_storage_server_accounts Number of ACME-DNS accounts in the storage per server URL.
+# TYPE goacmedns_storage_server_accounts gauge
+goacmedns_storage_server_accounts{server_url=""} 2
+goacmedns_storage_server_accounts{server_url="https://auth.acme-dns.io"} 1
+`
+
+	err := testutil.CollectAndCompare(NewCollector(st), strings.NewReader(expected))
+	if err != nil {
+		t.Error(err)
+	}
+}
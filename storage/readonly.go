@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// ErrReadOnly is returned when modifying or saving a [File] created with [NewReadOnlyFS].
+var ErrReadOnly = errors.New("storage is read-only")
+
+// NewReadOnlyFS returns a [File] storage loaded from the file at `path` in the given [fs.FS],
+// e.g. account data embedded into the binary with `go:embed`, or baked into an immutable container image.
+// The `path` must be a valid [fs.FS] path (slash-separated, without leading slash).
+// Unlike [NewFile], an error is returned if the file is missing or malformed.
+// The accounts can be fetched and reloaded, but [File.Save], [File.Put] and the other changes return [ErrReadOnly].
+func NewReadOnlyFS(fsys fs.FS, path string) (*File, error) {
+	f := &File{
+		path:     path,
+		accounts: make(map[string]goacmedns.Account),
+		codec:    jsonCodec,
+		fsys:     readOnlyFilesystem{fsys: fsys},
+		readOnly: true,
+	}
+
+	err := f.load(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// readOnlyFilesystem is a [Filesystem] reading from an [fs.FS], and rejecting the writes with [ErrReadOnly].
+type readOnlyFilesystem struct {
+	fsys fs.FS
+}
+
+func (r readOnlyFilesystem) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(r.fsys, name)
+}
+
+func (readOnlyFilesystem) WriteFile(name string, _ []byte, _ fs.FileMode) error {
+	return fmt.Errorf("write %s: %w", name, ErrReadOnly)
+}
+
+func (readOnlyFilesystem) Rename(oldpath, _ string) error {
+	return fmt.Errorf("rename %s: %w", oldpath, ErrReadOnly)
+}
+
+func (readOnlyFilesystem) Remove(name string) error {
+	return fmt.Errorf("remove %s: %w", name, ErrReadOnly)
+}
+
+func (r readOnlyFilesystem) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(r.fsys, name)
+}
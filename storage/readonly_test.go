@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewReadOnlyFS(t *testing.T) {
+	data, err := fs.ReadFile(os.DirFS("testdata"), "accounts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{"config/accounts.json": {Data: data}}
+
+	ctx := context.Background()
+
+	st, err := NewReadOnlyFS(fsys, "config/accounts.json")
+	if err != nil {
+		t.Fatalf("unexpected error loading storage: %v", err)
+	}
+
+	accounts, err := st.FetchAll(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error fetching accounts: %v", err)
+	}
+
+	if !reflect.DeepEqual(accounts, testAccounts) {
+		t.Errorf("expected accounts %#v, got %#v", testAccounts, accounts)
+	}
+
+	err = st.Reload(ctx)
+	if err != nil {
+		t.Errorf("unexpected error reloading storage: %v", err)
+	}
+
+	changes := map[string]func() error{
+		"Save":   func() error { return st.Save(ctx) },
+		"Put":    func() error { return st.Put(ctx, "example.com", testAccounts["lettuceencrypt.org"]) },
+		"Delete": func() error { return st.Delete(ctx, "lettuceencrypt.org") },
+		"Merge":  func() error { return st.Merge(ctx, nil, PreferIncoming) },
+		"Migrate": func() error {
+			_, err := st.Migrate(ctx, "https://auth.example.org")
+			return err
+		},
+		"Prune": func() error {
+			_, err := st.Prune(ctx, nil)
+			return err
+		},
+	}
+
+	for name, change := range changes {
+		err = change()
+		if !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", name, err)
+		}
+	}
+
+	if accounts, _ := st.FetchAll(ctx); !reflect.DeepEqual(accounts, testAccounts) {
+		t.Errorf("expected the accounts to be unchanged, got %#v", accounts)
+	}
+}
+
+func TestNewReadOnlyFS_errors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"malformed.json": {Data: []byte(`{`)},
+	}
+
+	testCases := []struct {
+		Name        string
+		Path        string
+		ExpectedErr error
+	}{
+		{Name: "missing file", Path: "accounts.json", ExpectedErr: fs.ErrNotExist},
+		{Name: "invalid path", Path: "/accounts.json"},
+		{Name: "malformed file", Path: "malformed.json"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := NewReadOnlyFS(fsys, tc.Path)
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+
+			if tc.ExpectedErr != nil && !errors.Is(err, tc.ExpectedErr) {
+				t.Errorf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+		})
+	}
+}
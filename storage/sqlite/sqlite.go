@@ -0,0 +1,215 @@
+// Package sqlite provides a [goacmedns.Storage] implementation backed by a SQLite database,
+// using a CGo-free driver.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+	_ "modernc.org/sqlite" // Registers the "sqlite" driver.
+)
+
+// busyTimeout is how long the statements wait for a lock held by another connection to the database.
+const busyTimeout = 5 * time.Second
+
+const schema = `CREATE TABLE IF NOT EXISTS accounts (
+	domain       TEXT PRIMARY KEY,
+	fulldomain   TEXT NOT NULL,
+	subdomain    TEXT NOT NULL,
+	username     TEXT NOT NULL,
+	password     TEXT NOT NULL,
+	server_url   TEXT NOT NULL,
+	allowfrom    TEXT NOT NULL,
+	created_at   TEXT NOT NULL,
+	last_used_at TEXT NOT NULL
+)`
+
+const upsertAccount = `INSERT INTO accounts
+	(domain, fulldomain, subdomain, username, password, server_url, allowfrom, created_at, last_used_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (domain) DO UPDATE SET
+		fulldomain = excluded.fulldomain,
+		subdomain = excluded.subdomain,
+		username = excluded.username,
+		password = excluded.password,
+		server_url = excluded.server_url,
+		allowfrom = excluded.allowfrom,
+		created_at = excluded.created_at,
+		last_used_at = excluded.last_used_at`
+
+const selectAccounts = `SELECT
+	domain, fulldomain, subdomain, username, password, server_url, allowfrom, created_at, last_used_at
+	FROM accounts`
+
+var _ goacmedns.Storage = (*Storage)(nil)
+
+// Storage implements the [goacmedns.Storage] interface and persists the accounts in a SQLite database.
+// Each account is stored as a row of the `accounts` table keyed by domain, with a column for each [goacmedns.Account] field.
+// It is safe for concurrent use.
+type Storage struct {
+	db *sql.DB
+}
+
+// New opens the SQLite database at the given path, creating it and the `accounts` table if required,
+// and returns a [Storage] persisting the accounts into it.
+// The database must be closed with [Storage.Close].
+func New(ctx context.Context, path string) (*Storage, error) {
+	dsn := (&url.URL{
+		Scheme:   "file",
+		Opaque:   path,
+		RawQuery: url.Values{"_pragma": {fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds())}}.Encode(),
+	}).String()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, schema)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to create schema: %w", err), db.Close())
+	}
+
+	return &Storage{db: db}, nil
+}
+
+// Close closes the database.
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// Save is a no-op: the accounts are written to the database by [Storage.Put].
+func (s *Storage) Save(_ context.Context) error {
+	return nil
+}
+
+// Put writes the [goacmedns.Account] for the given `domain` to the database, in a transaction.
+// The [goacmedns.Account] is checked with [goacmedns.Account.Validate] before being written.
+func (s *Storage) Put(ctx context.Context, domain string, acct goacmedns.Account) error {
+	err := acct.Validate()
+	if err != nil {
+		return fmt.Errorf("account for %q: %w", domain, err)
+	}
+
+	allowFrom, err := json.Marshal(acct.AllowFrom)
+	if err != nil {
+		return fmt.Errorf("failed to marshal allowfrom of %q: %w", domain, err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to put account for %q: %w", domain, err)
+	}
+
+	_, err = tx.ExecContext(ctx, upsertAccount,
+		domain, acct.FullDomain, acct.SubDomain, acct.Username, acct.Password, acct.ServerURL,
+		string(allowFrom), formatTime(acct.CreatedAt), formatTime(acct.LastUsedAt))
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to put account for %q: %w", domain, err), tx.Rollback())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to put account for %q: %w", domain, err)
+	}
+
+	return nil
+}
+
+// Fetch retrieves the [goacmedns.Account] for the given `domain` from the database.
+// If the `domain` provided does not have a [goacmedns.Account] in the database an [storage.ErrDomainNotFound] error is returned.
+func (s *Storage) Fetch(ctx context.Context, domain string) (goacmedns.Account, error) {
+	row := s.db.QueryRowContext(ctx, selectAccounts+" WHERE domain = ?", domain)
+
+	_, acct, err := scanAccount(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return goacmedns.Account{}, storage.ErrDomainNotFound
+	}
+
+	if err != nil {
+		return goacmedns.Account{}, fmt.Errorf("failed to fetch account for %q: %w", domain, err)
+	}
+
+	return acct, nil
+}
+
+// FetchAll returns a map that has domain names as its keys and [goacmedns.Account] objects as values.
+func (s *Storage) FetchAll(ctx context.Context) (map[string]goacmedns.Account, error) {
+	rows, err := s.db.QueryContext(ctx, selectAccounts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	accounts := make(map[string]goacmedns.Account)
+
+	for rows.Next() {
+		domain, acct, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch accounts: %w", err)
+		}
+
+		accounts[domain] = acct
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// scanAccount reads the domain and the [goacmedns.Account] of a row selected with selectAccounts.
+func scanAccount(row interface{ Scan(dest ...any) error }) (string, goacmedns.Account, error) {
+	var (
+		domain     string
+		acct       goacmedns.Account
+		allowFrom  string
+		createdAt  string
+		lastUsedAt string
+	)
+
+	err := row.Scan(&domain, &acct.FullDomain, &acct.SubDomain, &acct.Username, &acct.Password, &acct.ServerURL,
+		&allowFrom, &createdAt, &lastUsedAt)
+	if err != nil {
+		return "", goacmedns.Account{}, err
+	}
+
+	err = json.Unmarshal([]byte(allowFrom), &acct.AllowFrom)
+	if err != nil {
+		return "", goacmedns.Account{}, fmt.Errorf("invalid allowfrom of %q: %w", domain, err)
+	}
+
+	acct.CreatedAt = parseTime(createdAt)
+	acct.LastUsedAt = parseTime(lastUsedAt)
+
+	return domain, acct, nil
+}
+
+// formatTime formats the time as RFC 3339, or as an empty string if it is zero.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseTime parses a time formatted by formatTime, returning the zero time if it is empty or malformed.
+func parseTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nrdcg/goacmedns"
+	"github.com/nrdcg/goacmedns/storage"
+)
+
+var testAccounts = map[string]goacmedns.Account{
+	"lettuceencrypt.org": {
+		FullDomain: "lettuceencrypt.org",
+		SubDomain:  "tossed.lettuceencrypt.org",
+		Username:   "cpu",
+		Password:   "hunter2",
+		ServerURL:  "https://auth.acme-dns.io",
+		AllowFrom:  []string{"192.168.100.1/24"},
+	},
+	"threeletter.agency": {
+		FullDomain: "threeletter.agency",
+		SubDomain:  "jobs.threeletter.agency",
+		Username:   "spooky.mulder",
+		Password:   "trustno1",
+		ServerURL:  "https://example.org",
+		CreatedAt:  time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC),
+		LastUsedAt: time.Date(2025, time.March, 1, 8, 30, 0, 0, time.UTC),
+	},
+}
+
+func setupStorage(t *testing.T, path string) *Storage {
+	t.Helper()
+
+	st, err := New(context.Background(), path)
+	if err != nil {
+		t.Fatalf("unexpected error opening storage: %v", err)
+	}
+
+	t.Cleanup(func() { _ = st.Close() })
+
+	return st
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "accounts.db")
+
+	st := setupStorage(t, path)
+
+	for domain, acct := range testAccounts {
+		err := st.Put(ctx, domain, acct)
+		if err != nil {
+			t.Fatalf("unexpected error putting account: %v", err)
+		}
+	}
+
+	for domain, expected := range testAccounts {
+		acct, err := st.Fetch(ctx, domain)
+		if err != nil {
+			t.Fatalf("unexpected error fetching account for %q: %v", domain, err)
+		}
+
+		if !reflect.DeepEqual(acct, expected) {
+			t.Errorf("expected account %#v, got %#v", expected, acct)
+		}
+	}
+
+	// The accounts are persisted without Save.
+	reopened := setupStorage(t, path)
+
+	accounts, err := reopened.FetchAll(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error fetching all accounts: %v", err)
+	}
+
+	if !reflect.DeepEqual(accounts, testAccounts) {
+		t.Errorf("expected accounts %#v, got %#v", testAccounts, accounts)
+	}
+}
+
+func TestStorage_Put_overwrite(t *testing.T) {
+	ctx := context.Background()
+
+	st := setupStorage(t, filepath.Join(t.TempDir(), "accounts.db"))
+
+	acct := testAccounts["lettuceencrypt.org"]
+
+	err := st.Put(ctx, "lettuceencrypt.org", acct)
+	if err != nil {
+		t.Fatalf("unexpected error putting account: %v", err)
+	}
+
+	acct.Password = "hunter3"
+	acct.AllowFrom = nil
+
+	err = st.Put(ctx, "lettuceencrypt.org", acct)
+	if err != nil {
+		t.Fatalf("unexpected error overwriting account: %v", err)
+	}
+
+	fetched, err := st.Fetch(ctx, "lettuceencrypt.org")
+	if err != nil {
+		t.Fatalf("unexpected error fetching account: %v", err)
+	}
+
+	if !reflect.DeepEqual(fetched, acct) {
+		t.Errorf("expected account %#v, got %#v", acct, fetched)
+	}
+}
+
+func TestStorage_errors(t *testing.T) {
+	ctx := context.Background()
+
+	st := setupStorage(t, filepath.Join(t.TempDir(), "accounts.db"))
+
+	_, err := st.Fetch(ctx, "missing.example.com")
+	if !errors.Is(err, storage.ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound, got %v", err)
+	}
+
+	err = st.Put(ctx, "invalid.example.com", goacmedns.Account{FullDomain: "invalid.example.com"})
+	if !errors.Is(err, goacmedns.ErrInvalidAccount) {
+		t.Errorf("expected ErrInvalidAccount, got %v", err)
+	}
+
+	_, err = New(ctx, filepath.Join(t.TempDir(), "missing", "accounts.db"))
+	if err == nil {
+		t.Error("expected an error opening a database in a missing directory, got nil")
+	}
+}
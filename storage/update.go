@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// Update reloads the accounts from the file's configured `path`, applies `fn` to them, and persists the result,
+// as a single transaction: with [WithFileLock], the exclusive lock of the storage file is held across the three steps,
+// so the changes made by other processes between the loading and the saving are never overwritten.
+// A missing storage file is handled as an empty one.
+// The map given to `fn` holds all the accounts by key, including the labeled accounts (see [LabeledKey]).
+// The accounts modified by `fn` are checked with [goacmedns.Account.Validate].
+// If `fn`, the validation or the write fails, the error is returned and the in-memory accounts are left unchanged.
+// For a [File] created with [NewFromEnv], `fn` is applied to the in-memory accounts, which are not persisted.
+func (f *File) Update(ctx context.Context, fn func(accounts map[string]goacmedns.Account) error) (err error) {
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.inMemory {
+		updated, errChange := applyUpdate(f.accounts, fn)
+		if errChange != nil {
+			return errChange
+		}
+
+		f.accounts = updated
+
+		return nil
+	}
+
+	if f.fileLock {
+		release, errLock := lockFile(ctx, f.path, true)
+		if errLock != nil {
+			return errLock
+		}
+
+		defer func() { err = errors.Join(err, release()) }()
+	}
+
+	accounts, err := f.read()
+	if errors.Is(err, fs.ErrNotExist) {
+		accounts = make(map[string]goacmedns.Account)
+	} else if err != nil {
+		return err
+	}
+
+	updated, err := applyUpdate(accounts, fn)
+	if err != nil {
+		return err
+	}
+
+	err = f.write(ctx, updated)
+	if err != nil {
+		return err
+	}
+
+	f.accounts = updated
+
+	return nil
+}
+
+// applyUpdate applies `fn` to a copy of the accounts, and validates the added and modified accounts.
+func applyUpdate(accounts map[string]goacmedns.Account, fn func(map[string]goacmedns.Account) error) (map[string]goacmedns.Account, error) {
+	updated := maps.Clone(accounts)
+
+	err := fn(updated)
+	if err != nil {
+		return nil, err
+	}
+
+	for domain, acct := range updated {
+		if previous, exists := accounts[domain]; exists && previous.Equal(acct) {
+			continue
+		}
+
+		err = acct.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("account for %q: %w", domain, err)
+		}
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"reflect"
+	"testing"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+func TestFile_Update(t *testing.T) {
+	fsys := newMemFS()
+	ctx := context.Background()
+
+	// Another process saves an account after this one loaded the (missing) storage file.
+	other := NewFile("acmedns.json", 0o600, WithFilesystem(fsys))
+
+	storage := NewFile("acmedns.json", 0o600, WithFilesystem(fsys))
+
+	err := other.Put(ctx, "threeletter.agency", testAccounts["threeletter.agency"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = other.Save(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = storage.Update(ctx, func(accounts map[string]goacmedns.Account) error {
+		accounts["lettuceencrypt.org"] = testAccounts["lettuceencrypt.org"]
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error updating storage: %v", err)
+	}
+
+	if !reflect.DeepEqual(storage.accounts, testAccounts) {
+		t.Errorf("expected in-memory accounts %#v, got %#v", testAccounts, storage.accounts)
+	}
+
+	err = other.Reload(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error reloading storage: %v", err)
+	}
+
+	if !reflect.DeepEqual(other.accounts, testAccounts) {
+		t.Errorf("expected saved accounts %#v, got %#v", testAccounts, other.accounts)
+	}
+}
+
+func TestFile_Update_errors(t *testing.T) {
+	errUpdate := errors.New("update failed")
+
+	testCases := []struct {
+		Name        string
+		Fn          func(accounts map[string]goacmedns.Account) error
+		ExpectedErr error
+	}{
+		{
+			Name: "failed update",
+			Fn: func(accounts map[string]goacmedns.Account) error {
+				delete(accounts, "lettuceencrypt.org")
+
+				return errUpdate
+			},
+			ExpectedErr: errUpdate,
+		},
+		{
+			Name: "invalid account",
+			Fn: func(accounts map[string]goacmedns.Account) error {
+				accounts["example.com"] = goacmedns.Account{Username: "user"}
+
+				return nil
+			},
+			ExpectedErr: goacmedns.ErrInvalidAccount,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			fsys := newMemFS()
+			ctx := context.Background()
+
+			storage := NewFile("acmedns.json", 0o600, WithFilesystem(fsys))
+			storage.accounts = maps.Clone(testAccounts)
+
+			err := storage.Save(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = storage.Update(ctx, tc.Fn)
+			if !errors.Is(err, tc.ExpectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.ExpectedErr, err)
+			}
+
+			if !reflect.DeepEqual(storage.accounts, testAccounts) {
+				t.Errorf("expected unchanged accounts, got %#v", storage.accounts)
+			}
+
+			err = storage.Reload(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(storage.accounts, testAccounts) {
+				t.Errorf("expected unchanged storage file, got %#v", storage.accounts)
+			}
+		})
+	}
+}
+
+func TestFile_Update_readOnly(t *testing.T) {
+	storage := &File{readOnly: true}
+
+	err := storage.Update(context.Background(), func(map[string]goacmedns.Account) error { return nil })
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+// MarkUsed sets the `LastUsedAt` of the [goacmedns.Account] for the given `domain` to the given time,
+// then saves the storage. It is meant to be called after each successful [goacmedns.Client.UpdateTXTRecord],
+// so that the unused accounts can be detected with [File.Expired].
+// If the `domain` provided does not have a [goacmedns.Account] in the storage an [ErrDomainNotFound] error is returned.
+func MarkUsed(ctx context.Context, st goacmedns.Storage, domain string, at time.Time) error {
+	acct, err := st.Fetch(ctx, domain)
+	if err != nil {
+		return err
+	}
+
+	acct.LastUsedAt = at.UTC()
+
+	err = st.Put(ctx, domain, acct)
+	if err != nil {
+		return err
+	}
+
+	err = st.Save(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save storage: %w", err)
+	}
+
+	return nil
+}
+
+// Expired returns the sorted list of domains whose [goacmedns.Account] wasn't used for longer than `d`:
+// the `LastUsedAt` of the account, or its `CreatedAt` if it was never used, is older than `d`.
+// The accounts without any of these times (e.g. registered before they were recorded) are never considered expired.
+func (f *File) Expired(d time.Duration) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return expired(f.accounts, time.Now().Add(-d))
+}
+
+// expired returns the sorted list of domains whose accounts weren't used since the `threshold`.
+func expired(accounts map[string]goacmedns.Account, threshold time.Time) []string {
+	var domains []string
+
+	for domain, acct := range accounts {
+		lastActivity := acct.LastUsedAt
+		if lastActivity.IsZero() {
+			lastActivity = acct.CreatedAt
+		}
+
+		if !lastActivity.IsZero() && lastActivity.Before(threshold) {
+			domains = append(domains, domain)
+		}
+	}
+
+	slices.Sort(domains)
+
+	return domains
+}
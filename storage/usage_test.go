@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nrdcg/goacmedns"
+)
+
+func TestFile_Expired(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+
+	base := testAccounts["lettuceencrypt.org"]
+
+	accounts := map[string]goacmedns.Account{
+		"legacy.example.com":      base,
+		"recent.example.com":      withTimes(base, now.Add(-24*time.Hour), time.Time{}),
+		"stale.example.com":       withTimes(base, now.Add(-400*24*time.Hour), time.Time{}),
+		"used.example.com":        withTimes(base, now.Add(-400*24*time.Hour), now.Add(-time.Hour)),
+		"stale-usage.example.com": withTimes(base, now.Add(-800*24*time.Hour), now.Add(-400*24*time.Hour)),
+	}
+
+	fs := NewFile("accounts.json", 0o600, WithFilesystem(newMemFS()))
+
+	for domain, acct := range accounts {
+		err := fs.Put(ctx, domain, acct)
+		if err != nil {
+			t.Fatalf("unexpected error adding account to storage: %v", err)
+		}
+	}
+
+	expected := []string{"stale-usage.example.com", "stale.example.com"}
+
+	domains := fs.Expired(365 * 24 * time.Hour)
+	if !reflect.DeepEqual(domains, expected) {
+		t.Errorf("expected expired domains %v, got %v", expected, domains)
+	}
+}
+
+func TestMarkUsed(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "accounts.json")
+
+	fs := NewFile(path, 0o600)
+
+	created := time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	err := fs.Put(ctx, "lettuceencrypt.org", withTimes(testAccounts["lettuceencrypt.org"], created, time.Time{}))
+	if err != nil {
+		t.Fatalf("unexpected error adding account to storage: %v", err)
+	}
+
+	used := time.Date(2025, time.March, 1, 8, 30, 0, 0, time.UTC)
+
+	err = MarkUsed(ctx, fs, "lettuceencrypt.org", used)
+	if err != nil {
+		t.Fatalf("unexpected error marking the account as used: %v", err)
+	}
+
+	acct, _ := NewFile(path, 0o600).Fetch(ctx, "lettuceencrypt.org")
+	if !acct.LastUsedAt.Equal(used) || !acct.CreatedAt.Equal(created) {
+		t.Errorf("expected the account to be created at %s and used at %s, got %s and %s", created, used, acct.CreatedAt, acct.LastUsedAt)
+	}
+
+	err = MarkUsed(ctx, fs, "doesnotexist.example.com", used)
+	if !errors.Is(err, ErrDomainNotFound) {
+		t.Errorf("expected ErrDomainNotFound, got %v", err)
+	}
+}
+
+func TestFile_Save_zeroTimes(t *testing.T) {
+	testCases := []struct {
+		Name    string
+		NewFile func(path string, mode os.FileMode, opts ...FileOption) *File
+	}{
+		{Name: "JSON", NewFile: NewFile},
+		{Name: "YAML", NewFile: NewYAMLFile},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ctx := context.Background()
+
+			fsys := newMemFS()
+
+			fs := tc.NewFile("accounts", 0o600, WithFilesystem(fsys))
+
+			err := fs.Put(ctx, "lettuceencrypt.org", testAccounts["lettuceencrypt.org"])
+			if err != nil {
+				t.Fatalf("unexpected error adding account to storage: %v", err)
+			}
+
+			err = fs.Save(ctx)
+			if err != nil {
+				t.Fatalf("unexpected error saving: %v", err)
+			}
+
+			data, _ := fsys.ReadFile("accounts")
+			if strings.Contains(string(data), "created_at") || strings.Contains(string(data), "last_used_at") {
+				t.Errorf("expected the zero times to be omitted, got %s", data)
+			}
+		})
+	}
+}
+
+func withTimes(acct goacmedns.Account, createdAt, lastUsedAt time.Time) goacmedns.Account {
+	acct.CreatedAt = createdAt
+	acct.LastUsedAt = lastUsedAt
+
+	return acct
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+const (
+	tmpSuffix      = ".tmp"
+	backupSuffix   = ".bak"
+	checksumSuffix = ".sha256"
+)
+
+// ErrChecksumMismatch is returned when neither the storage file nor its backup match the checksum sidecar file.
+var ErrChecksumMismatch = errors.New("storage file does not match its checksum")
+
+// writeAtomic writes the data to a temporary file next to `path` and renames it to `path`.
+// The permissions of an existing file at `path` are kept, otherwise `mode` is used.
+// When the context is done before the rename, `path` is left unchanged and the temporary file is removed.
+func writeAtomic(ctx context.Context, fsys Filesystem, path string, data []byte, mode os.FileMode) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	if info, err := fsys.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp := path + tmpSuffix
+
+	err = fsys.WriteFile(tmp, data, mode)
+	if err != nil {
+		_ = fsys.Remove(tmp)
+
+		return err
+	}
+
+	err = ctx.Err()
+	if err != nil {
+		_ = fsys.Remove(tmp)
+
+		return err
+	}
+
+	err = fsys.Rename(tmp, path)
+	if err != nil {
+		_ = fsys.Remove(tmp)
+
+		return fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+
+	return nil
+}
+
+// writeWithChecksum writes the data in place, after having written a backup copy.
+// Each copy is written before its checksum sidecar file, so that a checksum never describes data not yet written:
+// if the in-place write is interrupted, [readWithChecksum] recovers the data from the backup copy,
+// and if the backup write is interrupted, the storage file still matches its checksum.
+// The permissions of an existing file at `path` are used for all the files, otherwise `mode` is used,
+// so that the backup copy doesn't expose the data more than the storage file.
+// The context is only checked before the first write: once the backup copy is written,
+// the data is recovered from it on load, so the remaining writes aren't interrupted.
+func writeWithChecksum(ctx context.Context, fsys Filesystem, path string, data []byte, mode os.FileMode) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	if info, err := fsys.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	sum := []byte(checksum(data))
+
+	err = fsys.WriteFile(path+backupSuffix, data, mode)
+	if err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+
+	err = fsys.WriteFile(path+backupSuffix+checksumSuffix, sum, mode)
+	if err != nil {
+		return fmt.Errorf("failed to write backup checksum file: %w", err)
+	}
+
+	err = fsys.WriteFile(path, data, mode)
+	if err != nil {
+		return err
+	}
+
+	err = fsys.WriteFile(path+checksumSuffix, sum, mode)
+	if err != nil {
+		return fmt.Errorf("failed to write checksum file: %w", err)
+	}
+
+	return nil
+}
+
+// readWithChecksum reads the file at `path` and verifies it against its checksum sidecar file.
+// When the verification fails, the backup copy is returned instead, if it matches its own checksum sidecar file
+// (or the checksum of the storage file, for the backups written without one).
+// Files without checksum sidecar files are returned as is.
+func readWithChecksum(fsys Filesystem, path string) ([]byte, error) {
+	data, err := fsys.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	sum, errSum := readChecksum(fsys, path+checksumSuffix)
+	if errSum != nil {
+		return nil, errSum
+	}
+
+	backupSum, errSum := readChecksum(fsys, path+backupSuffix+checksumSuffix)
+	if errSum != nil {
+		return nil, errSum
+	}
+
+	if sum == "" && backupSum == "" {
+		return data, err
+	}
+
+	if err == nil && sum != "" && checksum(data) == sum {
+		return data, nil
+	}
+
+	if backupSum == "" {
+		backupSum = sum
+	}
+
+	backup, err := fsys.ReadFile(path + backupSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup file: %w", err)
+	}
+
+	if checksum(backup) != backupSum {
+		return nil, ErrChecksumMismatch
+	}
+
+	return backup, nil
+}
+
+// readChecksum reads the checksum sidecar file with the given name, or returns an empty checksum if it doesn't exist.
+func readChecksum(fsys Filesystem, name string) (string, error) {
+	sum, err := fsys.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read checksum file: %w", err)
+	}
+
+	return string(bytes.TrimSpace(sum)), nil
+}
+
+// writeFileSync writes the data to the named file and flushes it to disk before closing it.
+func writeFileSync(name string, data []byte, mode os.FileMode) error {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	errClose := file.Close()
+
+	err = errors.Join(err, errClose)
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// checksum returns the hex-encoded SHA-256 sum of the data.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
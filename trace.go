@@ -0,0 +1,91 @@
+package goacmedns
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// traceHeadersKey is the context key of the headers set with [ContextWithTraceHeaders].
+type traceHeadersKey struct{}
+
+// ContextWithTraceHeaders returns a copy of the context carrying the tracing headers of the caller
+// (e.g. the W3C `traceparent` and `tracestate` headers of an incoming request),
+// forwarded to the ACME-DNS server by the clients created with [WithTraceHeaderPropagation].
+func ContextWithTraceHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, traceHeadersKey{}, headers.Clone())
+}
+
+// TraceHeadersFromContext returns the headers set with [ContextWithTraceHeaders], if any.
+func TraceHeadersFromContext(ctx context.Context) (http.Header, bool) {
+	headers, ok := ctx.Value(traceHeadersKey{}).(http.Header)
+
+	return headers, ok
+}
+
+// WithTraceHeaderPropagation wraps the transport of the client to forward the given headers
+// (e.g. `traceparent`, `tracestate`) when they are carried by the context of the requests (see [ContextWithTraceHeaders]),
+// to propagate the distributed traces without depending on a tracing library.
+// The propagated headers replace the headers with the same name of the outgoing requests.
+// With [WithHTTPClient], the given client is left unchanged: a copy of it with the wrapped transport is used.
+func WithTraceHeaderPropagation(keys []string) Option {
+	return func(c *Client) {
+		if c == nil {
+			return
+		}
+
+		c.traceHeaders = nil
+
+		for _, key := range keys {
+			c.traceHeaders = append(c.traceHeaders, http.CanonicalHeaderKey(key))
+		}
+	}
+}
+
+// applyTraceHeaderPropagation wraps the transport of the HTTP client with a [traceTransport].
+// It is applied after all the options, once the HTTP client is known.
+func (c *Client) applyTraceHeaderPropagation() {
+	httpClient := *c.httpClient
+	httpClient.Transport = &traceTransport{base: httpClient.Transport, keys: c.traceHeaders}
+
+	c.httpClient = &httpClient
+}
+
+// traceTransport is an [http.RoundTripper] copying the trace headers carried by the context of the requests
+// into their headers.
+type traceTransport struct {
+	base http.RoundTripper
+	keys []string
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	carried, ok := TraceHeadersFromContext(req.Context())
+	if !ok {
+		return base.RoundTrip(req)
+	}
+
+	// A RoundTripper must not modify the request.
+	req = req.Clone(req.Context())
+
+	for _, key := range t.keys {
+		if values := carried.Values(key); len(values) > 0 {
+			req.Header[key] = slices.Clone(values)
+		}
+	}
+
+	return base.RoundTrip(req)
+}
+
+// unwrapTransport returns the transport wrapped by [WithTraceHeaderPropagation], if any.
+func unwrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if t, ok := rt.(*traceTransport); ok {
+		return t.base
+	}
+
+	return rt
+}
@@ -0,0 +1,110 @@
+package goacmedns
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithTraceHeaderPropagation(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	client, mux := setupTest(t,
+		WithTraceHeaderPropagation([]string{"traceparent", "tracestate"}),
+		WithDefaultHeaders(http.Header{"Traceparent": []string{"default"}}),
+	)
+
+	var received []http.Header
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		received = append(received, req.Header.Clone())
+
+		updateTXTHandler(t)(resp, req)
+	})
+
+	carried := http.Header{}
+	carried.Set("Traceparent", traceparent)
+	carried.Set("Baggage", "not-forwarded")
+
+	ctx := ContextWithTraceHeaders(context.Background(), carried)
+
+	// The headers are copied into the context.
+	carried.Set("Traceparent", "changed")
+
+	for _, ctx := range []context.Context{ctx, context.Background()} {
+		err := client.UpdateTXTRecord(ctx, testAcct, updateValue)
+		if err != nil {
+			t.Fatalf("unexpected error updating TXT record: %v", err)
+		}
+	}
+
+	if got := received[0].Get("Traceparent"); got != traceparent {
+		t.Errorf("expected traceparent %q, got %q", traceparent, got)
+	}
+
+	if got := received[0].Get("Baggage"); got != "" {
+		t.Errorf("expected the baggage header not to be forwarded, got %q", got)
+	}
+
+	if _, ok := received[0]["Tracestate"]; ok {
+		t.Errorf("expected no tracestate header, got %q", received[0].Values("Tracestate"))
+	}
+
+	// Without trace headers in the context, the default header is sent.
+	if got := received[1].Get("Traceparent"); got != "default" {
+		t.Errorf("expected traceparent %q, got %q", "default", got)
+	}
+}
+
+func TestContextWithTraceHeaders_withoutPropagation(t *testing.T) {
+	client, mux := setupTest(t)
+
+	var traceparent string
+
+	mux.HandleFunc("/update", func(resp http.ResponseWriter, req *http.Request) {
+		traceparent = req.Header.Get("Traceparent")
+
+		updateTXTHandler(t)(resp, req)
+	})
+
+	ctx := ContextWithTraceHeaders(context.Background(), http.Header{"Traceparent": []string{"00-trace-span-01"}})
+
+	err := client.UpdateTXTRecord(ctx, testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT record: %v", err)
+	}
+
+	if traceparent != "" {
+		t.Errorf("expected no traceparent header, got %q", traceparent)
+	}
+}
+
+func TestWithTraceHeaderPropagation_httpClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		if got := req.Header.Get("Traceparent"); got != "00-trace-span-01" {
+			t.Errorf("expected the traceparent header to be forwarded, got %q", got)
+		}
+
+		_, _ = resp.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	httpClient := &http.Client{}
+
+	client, err := NewClient(server.URL, WithHTTPClient(httpClient), WithTraceHeaderPropagation([]string{"traceparent"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ContextWithTraceHeaders(context.Background(), http.Header{"Traceparent": []string{"00-trace-span-01"}})
+
+	err = client.UpdateTXTRecord(ctx, testAcct, updateValue)
+	if err != nil {
+		t.Fatalf("unexpected error updating TXT record: %v", err)
+	}
+
+	if httpClient.Transport != nil {
+		t.Errorf("expected the given HTTP client to be unchanged, got transport %T", httpClient.Transport)
+	}
+}